@@ -1,46 +1,109 @@
 package main
 
 import (
+	"github.com/PaytonWebber/aoc-discord-bot/internal/admin"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/archive"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/discord"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/lifecycle"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/profiles"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/scheduler"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/server"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/streaks"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/threads"
 
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
+	// Embed the timezone database, so that TIMEZONE and !timezone work on
+	// systems without one.
+	_ "time/tzdata"
 
 	"github.com/bwmarrin/discordgo"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	env := &dotEnv{}
+	if err := env.Load(); err != nil {
+		log.Fatalf("error loading configuration: %v", err)
+	}
 	cfg := loadConfig()
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	session := createDiscordSession(cfg)
 
-	storedLeaderboard := getLeaderboard(cfg)
+	client := aoc.NewClient(cfg.SessionCookie, cfg.AOCYear)
+	client.Titles = loadTitles(cfg)
+
+	store := leaderboard.NewStore(cfg.DataDir, cfg.SnapshotBackups)
+
+	storedLeaderboard := getLeaderboard(cfg, store)
 
-	tracker := initTracker(cfg, storedLeaderboard)
+	adminSettings := loadAdmin(cfg)
 
-	bot := initBotHandler(session, tracker, cfg)
+	tracker := initTracker(cfg, storedLeaderboard, client, store)
+	tracker.SetAliases(adminSettings.Get().Aliases)
+
+	bot := initBotHandler(ctx, session, tracker, loadProfiles(cfg), loadThreads(cfg), adminSettings, client, cfg)
+
+	bot.Archive = loadArchive(cfg)
+	bot.ReminderSchedule = scheduler.NewTimetable(bot.NextReminder, bot.OnReminder)
 
 	session.AddHandler(bot.MessageReceived)
 
-	setupSignalHandling(session, bot)
+	poller := scheduler.NewPoller(cfg.PollInterval.Duration, func(ctx context.Context) { checkForUpdates(ctx, bot) })
+
+	digest := scheduler.NewPoller(cfg.DigestInterval.Duration, bot.FlushDigest)
+
+	days := scheduler.NewTimetable(bot.NextDayChange, bot.OnDayChange)
+
+	reloader := &configReloader{env: env, current: cfg, client: client, tracker: tracker, bot: bot, poller: poller, digest: digest}
+
+	manager := lifecycle.NewManager(cfg.ShutdownTimeout.Duration)
+	manager.Add("poller", poller.Run)
+	manager.Add("digest", digest.Run)
+	manager.Add("day scheduler", days.Run)
+	manager.Add("reminders", bot.ReminderSchedule.Run)
+	manager.Add("archive backfill", func(ctx context.Context) error {
+		backfillArchive(ctx, cfg, bot.Archive, client)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	manager.Add("config reloader", reloader.Run)
+	if httpServer := newHTTPServer(cfg, session, tracker, poller); httpServer != nil {
+		manager.Add("HTTP server", httpServer.Run)
+	}
+	addShutdownHooks(manager, session, tracker, bot, store)
+
+	if err := manager.Run(ctx); err != nil {
+		log.Printf("error during shutdown: %v", err)
+		os.Exit(1)
+	}
+	log.Printf("Shutdown complete")
 }
 
 func loadConfig() *config.Config {
-	cfg := config.NewConfig()
-	if cfg == nil {
-		log.Fatal("cfg is nil")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("error loading configuration: %v", err)
 	}
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("configuration validation failed: %v", err)
 	}
-	log.Printf("Using Advent of Code year: %d", cfg.AOCYear)
+	log.Printf("Effective configuration: %s", cfg)
 	return cfg
 }
 
@@ -56,83 +119,315 @@ func createDiscordSession(cfg *config.Config) *discordgo.Session {
 	return session
 }
 
-func getLeaderboard(cfg *config.Config) *aoc.Leaderboard {
-	file, err := os.Open("leaderboard.json")
-	if err != nil || file == nil {
-		log.Printf("error opening leaderboard file: %v", err)
-		log.Printf("getting leaderboard from AoC")
-		client := aoc.NewClient(cfg.SessionCookie, cfg.AOCYear)
-		storedLeaderboard, err := client.GetLeaderboard(cfg.LeaderboardID)
-		return handleLeaderboardError(storedLeaderboard, err)
+// getLeaderboard loads the stored leaderboard snapshot. It returns nil when
+// there is no usable snapshot or it is older than BASELINE_MAX_AGE, so that
+// the first update records a fresh baseline instead of announcing everything
+// that happened in the meantime.
+func getLeaderboard(cfg *config.Config, store *leaderboard.Store) *aoc.Leaderboard {
+	storedLeaderboard, savedAt, err := store.Load()
+	if err != nil {
+		log.Printf("error loading leaderboard snapshot: %v", err)
+		log.Printf("starting without a baseline")
+		return nil
+	}
+	if age := time.Since(savedAt); age > cfg.BaselineMaxAge.Duration {
+		log.Printf("leaderboard snapshot is %s old, starting without a baseline", age.Round(time.Minute))
+		return nil
 	}
-	storedLeaderboard, err := leaderboard.GetLeaderboardFromFile(file)
-	return handleLeaderboardError(storedLeaderboard, err)
+	return storedLeaderboard
 }
 
-func handleLeaderboardError(leaderboard *aoc.Leaderboard, err error) *aoc.Leaderboard {
-	if err != nil {
-		log.Printf("error getting leaderboard: %v", err)
+// loadProfiles loads the users' linked members and preferences. If they
+// cannot be read the bot starts without them rather than not at all.
+func loadProfiles(cfg *config.Config) *profiles.Store {
+	store := profiles.NewStore(cfg.DataDir)
+	if err := store.Load(); err != nil {
+		log.Printf("error loading profiles: %v", err)
 	}
-	return leaderboard
+	return store
 }
 
-func initTracker(cfg *config.Config, storedLeaderboard *aoc.Leaderboard) *leaderboard.Tracker {
-	client := aoc.NewClient(cfg.SessionCookie, cfg.AOCYear)
+// loadAdmin loads the settings changed with admin commands. If they cannot
+// be read the bot starts with the configuration alone.
+func loadAdmin(cfg *config.Config) *admin.Store {
+	store := admin.NewStore(cfg.DataDir)
+	if err := store.Load(); err != nil {
+		log.Printf("error loading admin settings: %v", err)
+	}
+	return store
+}
+
+// loadThreads loads the discussion threads opened so far. If they cannot be
+// read the bot starts without them, and opens a new thread for the current
+// day.
+func loadThreads(cfg *config.Config) *threads.Store {
+	store := threads.NewStore(cfg.DataDir)
+	if err := store.Load(); err != nil {
+		log.Printf("error loading threads: %v", err)
+	}
+	return store
+}
+
+// loadHistory loads the members' star history. If it cannot be read it is
+// rebuilt from the leaderboard, which still has every star of the year.
+func loadHistory(cfg *config.Config) *streaks.History {
+	history := streaks.NewHistory(cfg.DataDir)
+	if err := history.Load(); err != nil {
+		log.Printf("error loading star history: %v", err)
+	}
+	return history
+}
+
+// loadTitles loads the puzzle titles fetched so far. If they cannot be read
+// they are fetched again.
+func loadTitles(cfg *config.Config) *aoc.TitleCache {
+	titles := aoc.NewTitleCache(cfg.DataDir)
+	if err := titles.Load(); err != nil {
+		log.Printf("error loading puzzle titles: %v", err)
+	}
+	return titles
+}
+
+// loadArchive loads the leaderboards of past years. If they cannot be read
+// they are fetched again by the backfill.
+func loadArchive(cfg *config.Config) *archive.Archive {
+	leaderboards := archive.New(filepath.Join(cfg.DataDir, "archive"))
+	if err := leaderboards.Load(); err != nil {
+		log.Printf("error loading archived leaderboards: %v", err)
+	}
+	return leaderboards
+}
+
+// backfillArchive fetches the leaderboard of every finished event since the
+// first one that has not been archived yet. The tracked year is left to the
+// tracker, and archived by the reloader once AOC_YEAR moves on. The client's
+// rate limiter spaces the requests out.
+func backfillArchive(ctx context.Context, cfg *config.Config, leaderboards *archive.Archive, client *aoc.Client) {
+	var years []int
+	for year := aoc.FirstEvent; year <= aoc.LatestEvent(time.Now()); year++ {
+		if year != cfg.AOCYear {
+			years = append(years, year)
+		}
+	}
+	if err := leaderboards.Backfill(ctx, client, cfg.LeaderboardID, years); err != nil {
+		log.Printf("error backfilling the archive: %v", err)
+	}
+}
+
+func initTracker(cfg *config.Config, storedLeaderboard *aoc.Leaderboard, client *aoc.Client, store *leaderboard.Store) *leaderboard.Tracker {
 	tracker := leaderboard.NewTracker(cfg, storedLeaderboard, client)
 	if tracker == nil {
 		log.Fatal("tracker is nil")
 	}
+	tracker.Store = store
+	tracker.History = loadHistory(cfg)
 	return tracker
 }
 
-func initBotHandler(session *discordgo.Session, tracker *leaderboard.Tracker, cfg *config.Config) *discord.BotHandler {
-	bot := discord.NewBotHandler(session, tracker, cfg)
+// initBotHandler creates the bot handler and runs a first update check, which
+// also catches up on day threads and reward roles missed while the bot was
+// down.
+func initBotHandler(ctx context.Context, session *discordgo.Session, tracker *leaderboard.Tracker, profileStore *profiles.Store, threadStore *threads.Store, adminSettings *admin.Store, puzzles discord.PuzzleTitles, cfg *config.Config) *discord.BotHandler {
+	bot := discord.NewBotHandler(ctx, session, tracker, cfg)
 	if bot == nil {
 		log.Fatal("botHandler is nil")
 	}
-	checkForUpdates(bot)
+	bot.Profiles = profileStore
+	bot.Threads = threadStore
+	bot.Admin = adminSettings
+	bot.Puzzles = puzzles
+	checkForUpdates(ctx, bot)
 	return bot
 }
 
-func checkForUpdates(bot *discord.BotHandler) {
-	hadUpdates, err := bot.CheckForUpdates()
+func checkForUpdates(ctx context.Context, bot *discord.BotHandler) {
+	changes, err := bot.CheckForUpdates(ctx)
 	if err != nil {
 		log.Printf("error checking for updates: %v", err)
 	}
-	if !hadUpdates {
+	if !changes.HasUpdates() {
 		log.Printf("no updates")
 	}
 }
 
-func setupSignalHandling(session *discordgo.Session, bot *discord.BotHandler) {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+// configReloader re-reads the configuration on SIGHUP and applies it to the
+// running components. An invalid configuration is rejected and the previous
+// one stays in effect.
+type configReloader struct {
+	env     *dotEnv
+	current *config.Config
+	client  *aoc.Client
+	tracker *leaderboard.Tracker
+	bot     *discord.BotHandler
+	poller  *scheduler.Poller
+	digest  *scheduler.Poller
+}
 
-	// Start the periodic update check in a goroutine
-	go periodicUpdateCheck(bot)
+// dotEnv applies the .env file below the real environment: a variable the bot
+// was started with always wins over the file, and a variable removed from the
+// file is unset again on the next load.
+type dotEnv struct {
+	// applied are the variables set from the file by the last load.
+	applied map[string]bool
+}
 
-	// Wait for an interrupt signal to shutdown
-	<-signals
+// Load reads the .env file, if there is one, into the environment.
+func (e *dotEnv) Load() error {
+	values, err := godotenv.Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		values, err = nil, nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading .env file: %w", err)
+	}
 
-	// Perform final actions before shutting down
-	finalShutdownActions(session, bot)
+	previous := e.applied
+	e.applied = make(map[string]bool, len(values))
+	for key := range previous {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+		}
+	}
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set && !previous[key] {
+			continue
+		}
+		e.applied[key] = true
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("error setting %s from .env file: %w", key, err)
+		}
+	}
+	return nil
 }
 
-func periodicUpdateCheck(bot *discord.BotHandler) {
-	ticker := time.NewTicker(15 * time.Minute)
-	defer ticker.Stop()
+// Run reloads the configuration on every SIGHUP until ctx is cancelled.
+func (r *configReloader) Run(ctx context.Context) error {
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	defer signal.Stop(reloads)
+
 	for {
 		select {
-		case <-ticker.C:
-			checkForUpdates(bot)
+		case <-ctx.Done():
+			return nil
+		case <-reloads:
+			r.reload()
 		}
 	}
 }
 
-func finalShutdownActions(session *discordgo.Session, bot *discord.BotHandler) {
-	log.Printf("Shutting down...")
-	checkForUpdates(bot)
-	session.Close()
-	log.Printf("Session closed")
-	os.Exit(0)
+func (r *configReloader) reload() {
+	log.Printf("Reloading configuration...")
+
+	// Pick up edits to the .env file.
+	if err := r.env.Load(); err != nil {
+		log.Printf("config reload rejected: %v", err)
+		return
+	}
+
+	next, err := config.Load()
+	if err != nil {
+		log.Printf("config reload rejected: %v", err)
+		return
+	}
+	if err := next.Validate(); err != nil {
+		log.Printf("config reload rejected: %v", err)
+		return
+	}
+
+	if next.DiscordToken != r.current.DiscordToken {
+		log.Printf("DISCORD_TOKEN cannot be changed without a restart, keeping the current token")
+		next.DiscordToken = r.current.DiscordToken
+	}
+	if next.HTTPAddr != r.current.HTTPAddr {
+		log.Printf("HTTP_ADDR cannot be changed without a restart, keeping the current address")
+		next.HTTPAddr = r.current.HTTPAddr
+	}
+	if next.DataDir != r.current.DataDir || next.SnapshotBackups != r.current.SnapshotBackups {
+		log.Printf("DATA_DIR and SNAPSHOT_BACKUPS cannot be changed without a restart, keeping the current values")
+		next.DataDir = r.current.DataDir
+		next.SnapshotBackups = r.current.SnapshotBackups
+	}
+
+	changes := r.current.Diff(next)
+	if len(changes) == 0 {
+		log.Printf("Configuration unchanged")
+		return
+	}
+
+	r.archiveOutgoing(next)
+	r.client.Configure(next.SessionCookie, next.AOCYear)
+	r.tracker.ApplyConfig(next)
+	r.bot.ApplyConfig(next)
+	r.poller.SetInterval(next.PollInterval.Duration)
+	r.digest.SetInterval(next.DigestInterval.Duration)
+	r.current = next
+
+	summary := r.bot.Messages().Render(messages.ConfigReloaded, messages.Data{Lines: changes})
+	log.Print(summary)
+	r.bot.SendChannelMessage(r.bot.AnnouncementChannel(), summary)
+}
+
+// archiveOutgoing archives the tracked leaderboard when AOC_YEAR moves to
+// another event, as the backfill leaves the tracked year to the tracker. If
+// the event is not over yet, the next backfill fetches it again once it is.
+func (r *configReloader) archiveOutgoing(next *config.Config) {
+	year := r.current.AOCYear
+	if next.AOCYear == year || r.bot.Archive == nil {
+		return
+	}
+	current := r.tracker.Snapshot().Current
+	if current == nil {
+		return
+	}
+	if err := current.Check(year, r.current.LeaderboardID); err != nil {
+		log.Printf("not archiving the %d leaderboard: %v", year, err)
+		return
+	}
+	if err := r.bot.Archive.Put(year, current); err != nil {
+		log.Printf("error archiving the outgoing event: %v", err)
+		return
+	}
+	log.Printf("Archived the %d leaderboard", year)
+}
+
+// newHTTPServer creates the health and status endpoints if HTTP_ADDR is set.
+func newHTTPServer(cfg *config.Config, session *discordgo.Session, tracker *leaderboard.Tracker, poller *scheduler.Poller) *server.Server {
+	if cfg.HTTPAddr == "" {
+		return nil
+	}
+	sessionReady := func() bool {
+		session.RLock()
+		defer session.RUnlock()
+		return session.DataReady
+	}
+	return server.NewServer(cfg.HTTPAddr, tracker, poller, sessionReady, cfg.ReadyIntervals)
+}
+
+// addShutdownHooks registers the final actions before shutting down. They run
+// in order once the pollers and HTTP server have stopped, and share the
+// shutdown deadline, so a slow AoC request cannot hold up the exit.
+func addShutdownHooks(manager *lifecycle.Manager, session *discordgo.Session, tracker *leaderboard.Tracker, bot *discord.BotHandler, store *leaderboard.Store) {
+	manager.OnShutdown("final update check", func(ctx context.Context) error {
+		checkForUpdates(ctx, bot)
+		return nil
+	})
+	manager.OnShutdown("digest", func(ctx context.Context) error {
+		bot.FlushDigest(ctx)
+		return nil
+	})
+	manager.OnShutdown("leaderboard store", func(ctx context.Context) error {
+		current := tracker.Snapshot().Current
+		if current == nil {
+			return nil
+		}
+		return store.Save(current)
+	})
+	manager.OnShutdown("discord session", func(ctx context.Context) error {
+		if err := session.Close(); err != nil {
+			return err
+		}
+		log.Printf("Session closed")
+		return nil
+	})
 }
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultErrorLogSize is how many errors an error log keeps.
+const DefaultErrorLogSize = 10
+
+// ErrorEntry is an error the bot ran into.
+type ErrorEntry struct {
+	Time time.Time
+	Err  string
+}
+
+// ErrorLog keeps the most recent errors in a ring buffer, for admins to look
+// at. It is safe for concurrent use.
+type ErrorLog struct {
+	mu      sync.Mutex
+	entries []ErrorEntry
+	next    int
+	full    bool
+}
+
+// NewErrorLog creates an error log that keeps the last size errors.
+func NewErrorLog(size int) *ErrorLog {
+	return &ErrorLog{entries: make([]ErrorEntry, size)}
+}
+
+// Add records an error, dropping the oldest one if the log is full.
+func (l *ErrorLog) Add(at time.Time, err error) {
+	if err == nil || len(l.entries) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = ErrorEntry{Time: at, Err: err.Error()}
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns the recorded errors, newest first.
+func (l *ErrorLog) Recent() []ErrorEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	recent := make([]ErrorEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return recent
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorLog(t *testing.T) {
+	log := NewErrorLog(3)
+	assert.Empty(t, log.Recent(), "A new log should be empty")
+
+	start := time.Unix(0, 0)
+	for i, message := range []string{"one", "two"} {
+		log.Add(start.Add(time.Duration(i)*time.Minute), errors.New(message))
+	}
+	log.Add(start, nil)
+	assert.Equal(t, []ErrorEntry{
+		{Time: start.Add(time.Minute), Err: "two"},
+		{Time: start, Err: "one"},
+	}, log.Recent(), "Errors should be listed newest first, ignoring nil")
+
+	for i, message := range []string{"three", "four"} {
+		log.Add(start.Add(time.Duration(i+2)*time.Minute), errors.New(message))
+	}
+	recent := log.Recent()
+	if assert.Len(t, recent, 3, "Only the last three errors should be kept") {
+		assert.Equal(t, "four", recent[0].Err, "The newest error should come first")
+		assert.Equal(t, "two", recent[2].Err, "The oldest error should have been dropped")
+	}
+}
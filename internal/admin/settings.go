@@ -0,0 +1,113 @@
+// Package admin keeps the settings admins change while the bot runs and the
+// bot's most recent errors.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const settingsFile = "admin.json"
+
+// Settings are changed with admin commands and override the configuration.
+// A zero field leaves the configuration in effect.
+type Settings struct {
+	// Paused stops announcements until they are resumed.
+	Paused bool `json:"paused,omitempty"`
+	// ChannelID is the channel announcements go to instead of CHANNEL_ID.
+	ChannelID string `json:"channel_id,omitempty"`
+	// Aliases are names members are shown under, by AoC member ID.
+	Aliases map[int]string `json:"aliases,omitempty"`
+}
+
+// Store keeps the settings in a JSON file in the data directory. It is safe
+// for concurrent use.
+type Store struct {
+	path     string
+	mu       sync.RWMutex
+	settings Settings
+}
+
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, settingsFile)}
+}
+
+// Load reads the stored settings. A missing file is not an error, it just
+// means no admin has changed anything yet.
+func (s *Store) Load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading admin settings: %w", err)
+	}
+
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("error unmarshalling admin settings %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	return nil
+}
+
+// Get returns a copy of the settings.
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings.clone()
+}
+
+// Update changes the settings with fn and saves them. If saving fails the
+// change is undone.
+func (s *Store) Update(fn func(*Settings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.settings
+	settings := previous.clone()
+	fn(&settings)
+	s.settings = settings
+
+	if err := s.save(); err != nil {
+		s.settings = previous
+		return err
+	}
+	return nil
+}
+
+func (s Settings) clone() Settings {
+	clone := s
+	if s.Aliases != nil {
+		clone.Aliases = make(map[int]string, len(s.Aliases))
+		for id, alias := range s.Aliases {
+			clone.Aliases[id] = alias
+		}
+	}
+	return clone
+}
+
+// save writes the settings atomically. The caller must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling admin settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("error storing admin settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreUpdateAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	err := store.Update(func(s *Settings) {
+		s.Paused = true
+		s.ChannelID = "channel-2"
+		s.Aliases = map[int]string{42: "Eve"}
+	})
+	assert.NoError(t, err, "Update should not return an error")
+
+	reloaded := NewStore(dir)
+	assert.NoError(t, reloaded.Load(), "Load should not return an error")
+	expected := Settings{Paused: true, ChannelID: "channel-2", Aliases: map[int]string{42: "Eve"}}
+	assert.Equal(t, expected, reloaded.Get(), "Settings should be stored")
+}
+
+func TestStoreGetReturnsCopy(t *testing.T) {
+	store := NewStore(t.TempDir())
+	assert.NoError(t, store.Update(func(s *Settings) { s.Aliases = map[int]string{1: "Alice"} }))
+
+	settings := store.Get()
+	settings.Aliases[1] = "Mallory"
+
+	assert.Equal(t, "Alice", store.Get().Aliases[1], "Changing a copy should not change the store")
+}
+
+func TestStoreUpdate_SaveFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not-a-dir")
+	assert.NoError(t, os.WriteFile(dir, nil, 0o644))
+	store := NewStore(dir)
+
+	err := store.Update(func(s *Settings) { s.Paused = true })
+
+	assert.Error(t, err, "Update should fail when the settings cannot be saved")
+	assert.False(t, store.Get().Paused, "A failed update should be undone")
+}
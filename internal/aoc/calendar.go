@@ -0,0 +1,112 @@
+package aoc
+
+import "time"
+
+// UnlockZone is the timezone puzzles unlock in. Advent of Code runs in
+// December, so US Eastern is always on standard time (UTC-5).
+var UnlockZone = time.FixedZone("EST", -5*60*60)
+
+// FirstEvent is the year of the first Advent of Code.
+const FirstEvent = 2015
+
+// MaxDays is the most puzzles any event has.
+const MaxDays = 25
+
+// UnlockTime returns when the puzzle for day of the given event unlocks:
+// midnight US Eastern on that day of December.
+func UnlockTime(year, day int) time.Time {
+	return time.Date(year, time.December, day, 0, 0, 0, 0, UnlockZone)
+}
+
+// LatestEvent returns the year of the most recent event that has started at
+// now.
+func LatestEvent(now time.Time) int {
+	year := now.In(UnlockZone).Year()
+	if now.Before(UnlockTime(year, 1)) {
+		return year - 1
+	}
+	return year
+}
+
+// Calendar is the schedule of one event: how many puzzles it has and when
+// each of them unlocks. Days are numbered from 1 and unlock one a day.
+type Calendar struct {
+	Year int
+	Days int
+}
+
+// CalendarFor returns the calendar of the given year's event. Events had 25
+// puzzles up to 2024, and have 12 from 2025 on.
+func CalendarFor(year int) Calendar {
+	if year >= 2025 {
+		return Calendar{Year: year, Days: 12}
+	}
+	return Calendar{Year: year, Days: MaxDays}
+}
+
+// Valid reports whether the event has a puzzle for day.
+func (c Calendar) Valid(day int) bool {
+	return day >= 1 && day <= c.Days
+}
+
+// Unlock returns when the puzzle for day unlocks.
+func (c Calendar) Unlock(day int) time.Time {
+	return UnlockTime(c.Year, day)
+}
+
+// End returns when the last puzzle stops being current, 24 hours after it
+// unlocked.
+func (c Calendar) End() time.Time {
+	return c.Unlock(c.Days).Add(24 * time.Hour)
+}
+
+// Unlocked returns how many puzzles have unlocked at now. Those are days 1
+// up to the returned number.
+func (c Calendar) Unlocked(now time.Time) int {
+	for day := c.Days; day >= 1; day-- {
+		if !now.Before(c.Unlock(day)) {
+			return day
+		}
+	}
+	return 0
+}
+
+// IsUnlocked reports whether the puzzle for day exists and has unlocked at
+// now.
+func (c Calendar) IsUnlocked(day int, now time.Time) bool {
+	return c.Valid(day) && !now.Before(c.Unlock(day))
+}
+
+// CurrentDay returns the day whose puzzle unlocked within the last 24 hours
+// at now, or 0 if no puzzle of the event is current.
+func (c Calendar) CurrentDay(now time.Time) int {
+	day := c.Unlocked(now)
+	if day == 0 || !now.Before(c.Unlock(day).Add(24*time.Hour)) {
+		return 0
+	}
+	return day
+}
+
+// NextUnlock returns the first puzzle unlock of the event after now. ok is
+// false once every puzzle has unlocked.
+func (c Calendar) NextUnlock(now time.Time) (unlock time.Time, day int, ok bool) {
+	for day := 1; day <= c.Days; day++ {
+		if unlock := c.Unlock(day); unlock.After(now) {
+			return unlock, day, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// NextDayChange returns the first time after now that the current day of the
+// event changes: a puzzle unlocks, or the last one stops being current. ok is
+// false once the event is over.
+func (c Calendar) NextDayChange(now time.Time) (change time.Time, ok bool) {
+	if unlock, _, ok := c.NextUnlock(now); ok {
+		return unlock, true
+	}
+	if end := c.End(); end.After(now) {
+		return end, true
+	}
+	return time.Time{}, false
+}
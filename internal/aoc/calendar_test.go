@@ -0,0 +1,139 @@
+package aoc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnlockTime(t *testing.T) {
+	expected := time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC)
+	if unlock := UnlockTime(2024, 1); !unlock.Equal(expected) {
+		t.Errorf("Expected day 1 to unlock at %v, got %v", expected, unlock.UTC())
+	}
+
+	expected = time.Date(2024, 12, 25, 5, 0, 0, 0, time.UTC)
+	if unlock := UnlockTime(2024, 25); !unlock.Equal(expected) {
+		t.Errorf("Expected day 25 to unlock at %v, got %v", expected, unlock.UTC())
+	}
+}
+
+func TestCurrentDay(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		day  int
+	}{
+		{"Before The Event", time.Date(2024, 11, 30, 12, 0, 0, 0, time.UTC), 0},
+		{"At The First Unlock", time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC), 1},
+		{"Just Before The Next Unlock", time.Date(2024, 12, 2, 4, 59, 0, 0, time.UTC), 1},
+		{"Last Day", time.Date(2024, 12, 25, 20, 0, 0, 0, time.UTC), 25},
+		{"After The Event", time.Date(2024, 12, 26, 5, 0, 0, 0, time.UTC), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if day := CalendarFor(2024).CurrentDay(tt.now); day != tt.day {
+				t.Errorf("Expected day %d, got %d", tt.day, day)
+			}
+		})
+	}
+}
+
+func TestNextUnlock(t *testing.T) {
+	unlock, day, ok := CalendarFor(2024).NextUnlock(time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC))
+	if !ok || day != 2 || !unlock.Equal(UnlockTime(2024, 2)) {
+		t.Errorf("Expected day 2 to unlock next, got day %d at %v (ok=%v)", day, unlock, ok)
+	}
+
+	if _, _, ok := CalendarFor(2024).NextUnlock(time.Date(2024, 12, 25, 5, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Expected no unlock after day 25")
+	}
+}
+
+func TestNextDayChange(t *testing.T) {
+	change, ok := CalendarFor(2024).NextDayChange(time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC))
+	if !ok || !change.Equal(UnlockTime(2024, 2)) {
+		t.Errorf("Expected the next change at the day 2 unlock, got %v (ok=%v)", change, ok)
+	}
+
+	end := UnlockTime(2024, 25).Add(24 * time.Hour)
+	change, ok = CalendarFor(2024).NextDayChange(UnlockTime(2024, 25))
+	if !ok || !change.Equal(end) {
+		t.Errorf("Expected the last change when day %d ends, got %v (ok=%v)", 25, change, ok)
+	}
+
+	if _, ok := CalendarFor(2024).NextDayChange(end); ok {
+		t.Errorf("Expected no change after the event")
+	}
+}
+
+func TestLatestEvent(t *testing.T) {
+	if year := LatestEvent(time.Date(2024, 11, 30, 12, 0, 0, 0, time.UTC)); year != 2023 {
+		t.Errorf("Expected 2023 before the 2024 event started, got %d", year)
+	}
+	if year := LatestEvent(UnlockTime(2024, 1)); year != 2024 {
+		t.Errorf("Expected 2024 once the first puzzle unlocked, got %d", year)
+	}
+	if year := LatestEvent(time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)); year != 2024 {
+		t.Errorf("Expected 2024 on New Year's Day UTC, got %d", year)
+	}
+}
+
+func TestCalendarFor(t *testing.T) {
+	if days := CalendarFor(2024).Days; days != 25 {
+		t.Errorf("Expected 25 days in 2024, got %d", days)
+	}
+	if days := CalendarFor(2025).Days; days != 12 {
+		t.Errorf("Expected 12 days from 2025 on, got %d", days)
+	}
+}
+
+func TestCalendarUnlocked(t *testing.T) {
+	calendar := CalendarFor(2024)
+	tests := []struct {
+		name     string
+		now      time.Time
+		unlocked int
+	}{
+		{"Before The Event", time.Date(2024, 11, 30, 12, 0, 0, 0, time.UTC), 0},
+		{"At The First Unlock", UnlockTime(2024, 1), 1},
+		{"Mid Event", time.Date(2024, 12, 10, 12, 0, 0, 0, time.UTC), 10},
+		{"After The Event", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if unlocked := calendar.Unlocked(tt.now); unlocked != tt.unlocked {
+				t.Errorf("Expected %d days unlocked, got %d", tt.unlocked, unlocked)
+			}
+		})
+	}
+
+	if !calendar.IsUnlocked(10, time.Date(2024, 12, 10, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected day 10 to be unlocked on December 10")
+	}
+	if calendar.IsUnlocked(11, time.Date(2024, 12, 10, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected day 11 to be locked on December 10")
+	}
+}
+
+func TestCalendarTwelveDays(t *testing.T) {
+	calendar := CalendarFor(2025)
+	later := time.Date(2025, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	if unlocked := calendar.Unlocked(later); unlocked != 12 {
+		t.Errorf("Expected 12 days unlocked, got %d", unlocked)
+	}
+	if day := calendar.CurrentDay(later); day != 0 {
+		t.Errorf("Expected no current day after day 12, got %d", day)
+	}
+	if calendar.Valid(13) {
+		t.Errorf("Expected day 13 not to exist in 2025")
+	}
+	if end := calendar.End(); !end.Equal(UnlockTime(2025, 13)) {
+		t.Errorf("Expected the event to end when day 12 closes, got %v", end)
+	}
+	if _, ok := calendar.NextDayChange(calendar.End()); ok {
+		t.Errorf("Expected no change after the event")
+	}
+}
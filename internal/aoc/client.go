@@ -1,24 +1,41 @@
 package aoc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
 )
 
+// DefaultTimeout bounds every request made by a client created with NewClient.
+const DefaultTimeout = 30 * time.Second
+
 type Client struct {
 	SessionCookie string
 	HTTPClient    *http.Client
 	Year          int
+	// Limiter spaces out every request the client makes. A nil Limiter
+	// does not limit.
+	Limiter *RateLimiter
+	// Titles keeps the puzzle titles fetched so far. A nil Titles fetches
+	// a title every time it is asked for.
+	Titles *TitleCache
+
+	mu sync.RWMutex
 }
 
 // NewClient creates a new AOC client with the provided session cookie and year.
 func NewClient(sessionCookie string, year int) *Client {
 	return &Client{
 		SessionCookie: sessionCookie,
-		HTTPClient:    http.DefaultClient,
+		HTTPClient:    &http.Client{Timeout: DefaultTimeout},
 		Year:          year,
+		Limiter:       NewRateLimiter(DefaultRequestInterval),
 	}
 }
 
@@ -27,33 +44,85 @@ func (c *Client) SetHTTPClient(client *http.Client) {
 	c.HTTPClient = client
 }
 
+// Configure replaces the session cookie and year used for subsequent requests.
+func (c *Client) Configure(sessionCookie string, year int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SessionCookie = sessionCookie
+	c.Year = year
+}
+
 func (c *Client) GetLeaderboard(leaderboardID string) (*Leaderboard, error) {
-	url := fmt.Sprintf("https://adventofcode.com/%d/leaderboard/private/view/%s.json", c.Year, leaderboardID)
+	return c.GetLeaderboardContext(context.Background(), leaderboardID)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
+// GetLeaderboardContext fetches the private leaderboard, giving up when ctx is
+// cancelled.
+func (c *Client) GetLeaderboardContext(ctx context.Context, leaderboardID string) (*Leaderboard, error) {
+	c.mu.RLock()
+	year := c.Year
+	c.mu.RUnlock()
+	return c.GetYearLeaderboardContext(ctx, leaderboardID, year)
+}
 
-	req.Header.Set("User-Agent", "github.com/PaytonWebber/aoc-discord-bot by paytonwebber@gmail.com")
-	req.Header.Set("cookie", fmt.Sprintf("session=%s", c.SessionCookie))
+// GetYearLeaderboardContext fetches the private leaderboard of the given
+// year's event rather than the configured one.
+func (c *Client) GetYearLeaderboardContext(ctx context.Context, leaderboardID string, year int) (*Leaderboard, error) {
+	c.mu.RLock()
+	sessionCookie := c.SessionCookie
+	c.mu.RUnlock()
 
-	resp, err := c.HTTPClient.Do(req)
+	url := fmt.Sprintf("https://adventofcode.com/%d/leaderboard/private/view/%s.json", year, leaderboardID)
+
+	metrics.FetchAttempts.Inc()
+	start := time.Now()
+	defer func() {
+		metrics.FetchDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	resp, err := c.do(ctx, url, sessionCookie)
 	if err != nil {
-		return nil, fmt.Errorf("error making HTTP request: %w", err)
+		metrics.FetchFailures.WithLabelValues("http").Inc()
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		metrics.FetchFailures.WithLabelValues("read").Inc()
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
 	var leaderboard Leaderboard
 	err = json.Unmarshal(body, &leaderboard)
 	if err != nil {
+		metrics.FetchFailures.WithLabelValues("decode").Inc()
 		return nil, fmt.Errorf("error unmarshalling response body: %w", err)
 	}
 
 	return &leaderboard, nil
 }
+
+// do waits for the rate limiter and makes a GET request to Advent of Code
+// with the session cookie.
+func (c *Client) do(ctx context.Context, url, sessionCookie string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "github.com/PaytonWebber/aoc-discord-bot by paytonwebber@gmail.com")
+	req.Header.Set("cookie", fmt.Sprintf("session=%s", sessionCookie))
+
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("error waiting for the rate limiter: %w", err)
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request: %w", err)
+	}
+	return resp, nil
+}
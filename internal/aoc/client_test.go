@@ -1,10 +1,16 @@
 package aoc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 const mockLeaderboardJSON = `{
@@ -126,6 +132,29 @@ func TestGetLeaderboardInvalidSession(t *testing.T) {
 	}
 }
 
+func TestGetLeaderboardMetrics(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "invalid json")
+	}))
+	defer mockServer.Close()
+
+	client := NewClient("test-session-cookie", 2024)
+	client.SetHTTPClient(mockServer.Client())
+	client.HTTPClient.Transport = rewriteURLTransport("https://adventofcode.com", mockServer.URL)
+
+	attempts := testutil.ToFloat64(metrics.FetchAttempts)
+	failures := testutil.ToFloat64(metrics.FetchFailures.WithLabelValues("decode"))
+
+	client.GetLeaderboard("test-leaderboard")
+
+	if got := testutil.ToFloat64(metrics.FetchAttempts) - attempts; got != 1 {
+		t.Errorf("Expected 1 fetch attempt to be recorded, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.FetchFailures.WithLabelValues("decode")) - failures; got != 1 {
+		t.Errorf("Expected 1 decode failure to be recorded, got %v", got)
+	}
+}
+
 func TestGetLeaderboardInvalidJSON(t *testing.T) {
 	// Create a mock server that returns invalid JSON
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -172,3 +201,28 @@ func (t *urlRewritingTransport) RoundTrip(req *http.Request) (*http.Response, er
 	}
 	return t.original.RoundTrip(req)
 }
+
+func TestGetLeaderboardContextCancelled(t *testing.T) {
+	// Create a mock server that never answers before the request is cancelled
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer mockServer.Close()
+
+	client := NewClient("test-session-cookie", 2024)
+	client.SetHTTPClient(mockServer.Client())
+
+	// Override the request URL
+	client.HTTPClient.Transport = rewriteURLTransport("https://adventofcode.com", mockServer.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetLeaderboardContext(ctx, "test-leaderboard")
+	if err == nil {
+		t.Fatalf("Expected an error due to the cancelled context, but got none")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline exceeded error, got '%v'", err)
+	}
+}
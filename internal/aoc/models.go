@@ -1,5 +1,11 @@
 package aoc
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
 type StarDetail struct {
 	GetStarTs int `json:"get_star_ts"`
 	StarIndex int `json:"star_index"`
@@ -26,3 +32,23 @@ type Leaderboard struct {
 	Event   string            `json:"event"`
 	OwnerID int               `json:"owner_id"`
 }
+
+// Check rejects a leaderboard that cannot be the private leaderboard
+// leaderboardID of year's event. A year of 0 skips the event check, and an
+// ID that is not a number skips the owner check.
+func (l *Leaderboard) Check(year int, leaderboardID string) error {
+	if l == nil {
+		return errors.New("leaderboard is nil")
+	}
+	if len(l.Members) == 0 {
+		return errors.New("leaderboard has no members")
+	}
+	if year != 0 && l.Event != strconv.Itoa(year) {
+		return fmt.Errorf("leaderboard is for event %q, expected %d", l.Event, year)
+	}
+	// A private leaderboard's ID is its owner's user ID.
+	if ownerID, err := strconv.Atoi(leaderboardID); err == nil && l.OwnerID != ownerID {
+		return fmt.Errorf("leaderboard is owned by %d, expected %d", l.OwnerID, ownerID)
+	}
+	return nil
+}
@@ -115,3 +115,28 @@ func TestMemberUnmarshalWithMissingFields(t *testing.T) {
 		t.Errorf("Expected CompletionDayLevels to be nil, got %v", member.CompletionDayLevels)
 	}
 }
+
+func TestLeaderboardCheck(t *testing.T) {
+	leaderboard := &Leaderboard{
+		Event:   "2024",
+		OwnerID: 12345,
+		Members: map[string]Member{"1": {ID: 1, Name: "Alice"}},
+	}
+
+	if err := leaderboard.Check(2024, "12345"); err != nil {
+		t.Errorf("Expected the leaderboard to pass, got %v", err)
+	}
+	if err := leaderboard.Check(2023, "12345"); err == nil {
+		t.Errorf("Expected an error for another event")
+	}
+	if err := leaderboard.Check(2024, "999"); err == nil {
+		t.Errorf("Expected an error for another owner")
+	}
+	if err := (&Leaderboard{Event: "2024", OwnerID: 12345}).Check(2024, "12345"); err == nil {
+		t.Errorf("Expected an error for a leaderboard without members")
+	}
+	var missing *Leaderboard
+	if err := missing.Check(2024, "12345"); err == nil {
+		t.Errorf("Expected an error for a nil leaderboard")
+	}
+}
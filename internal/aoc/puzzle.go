@@ -0,0 +1,156 @@
+package aoc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const titlesFile = "titles.json"
+
+// titlePattern matches the heading of a puzzle page, e.g.
+// "<h2>--- Day 7: Camel Cards ---</h2>".
+var titlePattern = regexp.MustCompile(`<h2[^>]*>--- Day (\d+): (.+?) ---</h2>`)
+
+// PuzzleTitle returns the title of a puzzle, e.g. "Camel Cards" for day 7 of
+// 2023. A title is only fetched once; after that it comes from the cache.
+func (c *Client) PuzzleTitle(ctx context.Context, year, day int) (string, error) {
+	if title, ok := c.CachedPuzzleTitle(year, day); ok {
+		return title, nil
+	}
+	if !CalendarFor(year).IsUnlocked(day, time.Now()) {
+		return "", fmt.Errorf("day %d of %d has not unlocked yet", day, year)
+	}
+
+	c.mu.RLock()
+	sessionCookie := c.SessionCookie
+	c.mu.RUnlock()
+
+	url := fmt.Sprintf("https://adventofcode.com/%d/day/%d", year, day)
+	resp, err := c.do(ctx, url, sessionCookie)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error fetching day %d of %d: %s", day, year, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+
+	title, err := parsePuzzleTitle(body, day)
+	if err != nil {
+		return "", err
+	}
+	if c.Titles != nil {
+		if err := c.Titles.Put(year, day, title); err != nil {
+			return title, err
+		}
+	}
+	return title, nil
+}
+
+// CachedPuzzleTitle returns the title of a puzzle if it has been fetched
+// before. It never makes a request.
+func (c *Client) CachedPuzzleTitle(year, day int) (string, bool) {
+	if c.Titles == nil {
+		return "", false
+	}
+	return c.Titles.Get(year, day)
+}
+
+// parsePuzzleTitle finds the title of the puzzle for day in a puzzle page.
+func parsePuzzleTitle(page []byte, day int) (string, error) {
+	match := titlePattern.FindSubmatch(page)
+	if match == nil {
+		return "", fmt.Errorf("no puzzle title found for day %d", day)
+	}
+	if n, _ := strconv.Atoi(string(match[1])); n != day {
+		return "", fmt.Errorf("expected the title of day %d, found day %d", day, n)
+	}
+	return html.UnescapeString(string(match[2])), nil
+}
+
+// TitleCache keeps puzzle titles by year and day in a JSON file. Titles never
+// change, so they are kept forever. It is safe for concurrent use.
+type TitleCache struct {
+	path string
+
+	mu     sync.RWMutex
+	titles map[int]map[int]string
+}
+
+func NewTitleCache(dir string) *TitleCache {
+	return &TitleCache{
+		path:   filepath.Join(dir, titlesFile),
+		titles: make(map[int]map[int]string),
+	}
+}
+
+// Load reads the cached titles. A missing file is not an error.
+func (tc *TitleCache) Load() error {
+	data, err := os.ReadFile(tc.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading puzzle titles: %w", err)
+	}
+
+	titles := make(map[int]map[int]string)
+	if err := json.Unmarshal(data, &titles); err != nil {
+		return fmt.Errorf("error unmarshalling puzzle titles %s: %w", tc.path, err)
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.titles = titles
+	return nil
+}
+
+// Get returns the cached title of a puzzle.
+func (tc *TitleCache) Get(year, day int) (string, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	title, ok := tc.titles[year][day]
+	return title, ok
+}
+
+// Put adds a title to the cache and saves it. The title stays cached for
+// this run even if saving fails.
+func (tc *TitleCache) Put(year, day int, title string) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.titles[year] == nil {
+		tc.titles[year] = make(map[int]string)
+	}
+	tc.titles[year][day] = title
+
+	data, err := json.MarshalIndent(tc.titles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling puzzle titles: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(tc.path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(tc.path, data, 0o644); err != nil {
+		return fmt.Errorf("error storing puzzle titles: %w", err)
+	}
+	return nil
+}
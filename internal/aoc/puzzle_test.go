@@ -0,0 +1,113 @@
+package aoc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// puzzleServer serves the saved puzzle page in testdata/fixture, with the
+// given status, and counts the requests made to it.
+func puzzleServer(t *testing.T, fixture string, status int, requests *int) *Client {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("Expected to read fixture %s, got %v", fixture, err)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		w.Write(page)
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client := NewClient("test-session-cookie", 2023)
+	client.SetHTTPClient(mockServer.Client())
+	client.HTTPClient.Transport = rewriteURLTransport("https://adventofcode.com", mockServer.URL)
+	return client
+}
+
+func TestPuzzleTitle(t *testing.T) {
+	var requests int
+	client := puzzleServer(t, "2023_day7.html", http.StatusOK, &requests)
+
+	title, err := client.PuzzleTitle(context.Background(), 2023, 7)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if title != "Camel Cards" {
+		t.Errorf("Expected title 'Camel Cards', got '%s'", title)
+	}
+}
+
+func TestPuzzleTitleUnescapesHTML(t *testing.T) {
+	var requests int
+	client := puzzleServer(t, "2015_day5.html", http.StatusOK, &requests)
+
+	title, err := client.PuzzleTitle(context.Background(), 2015, 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := "Doesn't He Have Intern-Elves For This?"; title != expected {
+		t.Errorf("Expected title '%s', got '%s'", expected, title)
+	}
+}
+
+func TestPuzzleTitleWrongDay(t *testing.T) {
+	var requests int
+	client := puzzleServer(t, "2023_day7.html", http.StatusOK, &requests)
+
+	if _, err := client.PuzzleTitle(context.Background(), 2023, 8); err == nil {
+		t.Errorf("Expected an error for a page of another day")
+	}
+}
+
+func TestPuzzleTitleNotFound(t *testing.T) {
+	var requests int
+	client := puzzleServer(t, "not_found.html", http.StatusNotFound, &requests)
+
+	if _, err := client.PuzzleTitle(context.Background(), 2023, 7); err == nil {
+		t.Errorf("Expected an error for a missing puzzle")
+	}
+}
+
+func TestPuzzleTitleNotUnlocked(t *testing.T) {
+	var requests int
+	client := puzzleServer(t, "2023_day7.html", http.StatusOK, &requests)
+
+	if _, err := client.PuzzleTitle(context.Background(), 9999, 1); err == nil {
+		t.Errorf("Expected an error for a puzzle that has not unlocked")
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request before the unlock, got %d", requests)
+	}
+}
+
+func TestPuzzleTitleCached(t *testing.T) {
+	dir := t.TempDir()
+	var requests int
+	client := puzzleServer(t, "2023_day7.html", http.StatusOK, &requests)
+	client.Titles = NewTitleCache(dir)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.PuzzleTitle(context.Background(), 2023, 7); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the title to be fetched once, got %d requests", requests)
+	}
+
+	reloaded := NewTitleCache(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Expected no error loading the cache, got %v", err)
+	}
+	if title, ok := reloaded.Get(2023, 7); !ok || title != "Camel Cards" {
+		t.Errorf("Expected the cached title 'Camel Cards', got '%s' (ok=%v)", title, ok)
+	}
+}
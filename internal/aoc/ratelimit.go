@@ -0,0 +1,61 @@
+package aoc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRequestInterval is the least time between two requests to Advent of
+// Code made by a client created with NewClient.
+const DefaultRequestInterval = 5 * time.Second
+
+// RateLimiter spaces requests out so that no two start less than an interval
+// apart. It is shared by every kind of request a client makes.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// Wait blocks until a request may be made, and reserves that slot. It returns
+// early with ctx's error if ctx is cancelled, in which case the slot is not
+// used up.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(slot)
+		return ctx.Err()
+	}
+}
+
+// release gives back a reserved slot if no later one has been handed out.
+func (l *RateLimiter) release(slot time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.next.Equal(slot.Add(l.interval)) {
+		l.next = slot
+	}
+}
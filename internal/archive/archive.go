@@ -0,0 +1,174 @@
+// Package archive keeps the private leaderboard of every Advent of Code
+// event, so that past years can still be shown after AOC_YEAR has moved on.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+// Fetcher fetches the private leaderboard of one year's event.
+type Fetcher interface {
+	GetYearLeaderboardContext(ctx context.Context, leaderboardID string, year int) (*aoc.Leaderboard, error)
+}
+
+// Archive keeps one leaderboard per year as a JSON file in a directory. It is
+// safe for concurrent use.
+type Archive struct {
+	dir   string
+	mu    sync.RWMutex
+	years map[int]*aoc.Leaderboard
+	// saved is when each year's leaderboard was archived, which tells
+	// whether it was taken before the event was over.
+	saved map[int]time.Time
+}
+
+func New(dir string) *Archive {
+	return &Archive{
+		dir:   dir,
+		years: make(map[int]*aoc.Leaderboard),
+		saved: make(map[int]time.Time),
+	}
+}
+
+func (a *Archive) path(year int) string {
+	return filepath.Join(a.dir, strconv.Itoa(year)+".json")
+}
+
+// Load reads the archived leaderboards. A missing directory is not an error,
+// it just means nothing has been archived yet.
+func (a *Archive) Load() error {
+	entries, err := os.ReadDir(a.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading archive: %w", err)
+	}
+
+	years := make(map[int]*aoc.Leaderboard)
+	saved := make(map[int]time.Time)
+	for _, entry := range entries {
+		year, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("error reading archived leaderboard %d: %w", year, err)
+		}
+		data, err := os.ReadFile(a.path(year))
+		if err != nil {
+			return fmt.Errorf("error reading archived leaderboard %d: %w", year, err)
+		}
+		var leaderboard aoc.Leaderboard
+		if err := json.Unmarshal(data, &leaderboard); err != nil {
+			return fmt.Errorf("error unmarshalling archived leaderboard %d: %w", year, err)
+		}
+		years[year] = &leaderboard
+		saved[year] = info.ModTime()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.years = years
+	a.saved = saved
+	return nil
+}
+
+// Get returns the archived leaderboard of a year.
+func (a *Archive) Get(year int) (*aoc.Leaderboard, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	leaderboard, ok := a.years[year]
+	return leaderboard, ok
+}
+
+// All returns every archived leaderboard by year.
+func (a *Archive) All() map[int]*aoc.Leaderboard {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	years := make(map[int]*aoc.Leaderboard, len(a.years))
+	for year, leaderboard := range a.years {
+		years[year] = leaderboard
+	}
+	return years
+}
+
+// Put archives the leaderboard of a year, replacing any earlier one.
+func (a *Archive) Put(year int, leaderboard *aoc.Leaderboard) error {
+	data, err := json.Marshal(leaderboard)
+	if err != nil {
+		return fmt.Errorf("error marshalling leaderboard %d: %w", year, err)
+	}
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating archive directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(a.path(year), data, 0o644); err != nil {
+		return fmt.Errorf("error archiving leaderboard %d: %w", year, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.years[year] = leaderboard
+	a.saved[year] = time.Now()
+	return nil
+}
+
+// complete reports whether a year is archived with a leaderboard taken after
+// its event ended, which cannot change any more.
+func (a *Archive) complete(year int) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	saved, ok := a.saved[year]
+	return ok && saved.After(aoc.CalendarFor(year).End())
+}
+
+// Backfill fetches and archives the leaderboard of every given year whose
+// event has ended and that is not archived yet, or was archived before the
+// event ended. Events still running are skipped, as their leaderboard would
+// be kept as it is now. A fetched leaderboard must be leaderboardID's for the
+// year. Years that fail are reported and left for the next backfill; it
+// stops early if ctx is cancelled.
+func (a *Archive) Backfill(ctx context.Context, fetcher Fetcher, leaderboardID string, years []int) error {
+	sort.Ints(years)
+	now := time.Now()
+	var errs []error
+	for _, year := range years {
+		if a.complete(year) || now.Before(aoc.CalendarFor(year).End()) {
+			continue
+		}
+		leaderboard, err := fetcher.GetYearLeaderboardContext(ctx, leaderboardID, year)
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error fetching leaderboard %d: %w", year, err))
+			continue
+		}
+		if err := leaderboard.Check(year, leaderboardID); err != nil {
+			errs = append(errs, fmt.Errorf("error checking leaderboard %d: %w", year, err))
+			continue
+		}
+		if err := a.Put(year, leaderboard); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("Archived the %d leaderboard", year)
+	}
+	return errors.Join(errs...)
+}
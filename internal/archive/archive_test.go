@@ -0,0 +1,123 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFetcher struct {
+	fetched []int
+	fail    map[int]bool
+	// owner overrides the owner of the fetched leaderboards.
+	owner int
+}
+
+func (f *fakeFetcher) GetYearLeaderboardContext(ctx context.Context, leaderboardID string, year int) (*aoc.Leaderboard, error) {
+	f.fetched = append(f.fetched, year)
+	if f.fail[year] {
+		return nil, errors.New("fetch failed")
+	}
+	owner, _ := strconv.Atoi(leaderboardID)
+	if f.owner != 0 {
+		owner = f.owner
+	}
+	return &aoc.Leaderboard{
+		Event:   strconv.Itoa(year),
+		OwnerID: owner,
+		Members: map[string]aoc.Member{"1": {ID: 1, Name: "Alice"}},
+	}, nil
+}
+
+func TestArchivePutAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	archive := New(dir)
+	assert.NoError(t, archive.Put(2021, &aoc.Leaderboard{Event: "2021"}), "Put should not return an error")
+
+	reloaded := New(dir)
+	assert.NoError(t, reloaded.Load(), "Load should not return an error")
+
+	leaderboard, ok := reloaded.Get(2021)
+	assert.True(t, ok, "2021 should be archived")
+	assert.Equal(t, "2021", leaderboard.Event, "Archived leaderboard should match")
+	assert.Len(t, reloaded.All(), 1, "Only one year should be archived")
+}
+
+func TestArchiveLoadMissingDirectory(t *testing.T) {
+	archive := New(t.TempDir() + "/archive")
+
+	assert.NoError(t, archive.Load(), "A missing directory should not be an error")
+	assert.Empty(t, archive.All(), "Nothing should be archived")
+}
+
+func TestArchiveBackfill(t *testing.T) {
+	archive := New(t.TempDir())
+	assert.NoError(t, archive.Put(2016, &aoc.Leaderboard{Event: "2016"}))
+	fetcher := &fakeFetcher{fail: map[int]bool{2017: true}}
+
+	err := archive.Backfill(context.Background(), fetcher, "test-leaderboard", []int{2017, 2015, 2016})
+
+	assert.Error(t, err, "A failed year should be reported")
+	assert.Equal(t, []int{2015, 2017}, fetcher.fetched, "Only missing years should be fetched, in order")
+	_, ok := archive.Get(2015)
+	assert.True(t, ok, "2015 should be archived")
+	_, ok = archive.Get(2017)
+	assert.False(t, ok, "A failed year should not be archived")
+}
+
+func TestArchiveBackfillCancelled(t *testing.T) {
+	archive := New(t.TempDir())
+	fetcher := &fakeFetcher{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := archive.Backfill(ctx, fetcher, "test-leaderboard", []int{2015, 2016})
+
+	assert.ErrorIs(t, err, context.Canceled, "Cancellation should be reported")
+	assert.Len(t, fetcher.fetched, 1, "Backfill should stop after cancellation")
+}
+
+func TestArchiveBackfillRefetchesEarlySnapshots(t *testing.T) {
+	dir := t.TempDir()
+	archive := New(dir)
+	assert.NoError(t, archive.Put(2016, &aoc.Leaderboard{Event: "2016"}))
+	assert.NoError(t, archive.Put(2017, &aoc.Leaderboard{Event: "2017"}))
+	midEvent := aoc.CalendarFor(2016).Unlock(10)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "2016.json"), midEvent, midEvent))
+	assert.NoError(t, archive.Load())
+	fetcher := &fakeFetcher{}
+
+	err := archive.Backfill(context.Background(), fetcher, "12345", []int{2016, 2017})
+
+	assert.NoError(t, err, "Backfill should not return an error")
+	assert.Equal(t, []int{2016}, fetcher.fetched, "Only the year archived before its event ended should be fetched again")
+	leaderboard, _ := archive.Get(2016)
+	assert.Len(t, leaderboard.Members, 1, "The early snapshot should be replaced")
+}
+
+func TestArchiveBackfillSkipsRunningEvents(t *testing.T) {
+	archive := New(t.TempDir())
+	fetcher := &fakeFetcher{}
+	year := time.Now().Year() + 1
+
+	assert.NoError(t, archive.Backfill(context.Background(), fetcher, "12345", []int{year}))
+	assert.Empty(t, fetcher.fetched, "An event that has not ended should not be archived")
+}
+
+func TestArchiveBackfillChecksLeaderboards(t *testing.T) {
+	archive := New(t.TempDir())
+	fetcher := &fakeFetcher{owner: 999}
+
+	err := archive.Backfill(context.Background(), fetcher, "12345", []int{2015})
+
+	assert.Error(t, err, "A leaderboard of another owner should be reported")
+	_, ok := archive.Get(2015)
+	assert.False(t, ok, "A leaderboard of another owner should not be archived")
+}
@@ -1,21 +1,142 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/roles"
+)
+
+const (
+	// DefaultPollInterval is how often the leaderboard is fetched. Advent of
+	// Code asks that private leaderboards are not polled more often than this.
+	DefaultPollInterval = 15 * time.Minute
+	// DefaultReadyIntervals is how many poll intervals may pass without a
+	// successful fetch before the bot reports itself as not ready.
+	DefaultReadyIntervals = 3
+	// DefaultShutdownTimeout is how long the bot may take to shut down.
+	DefaultShutdownTimeout = 10 * time.Second
+	// DefaultDataDir is where the bot keeps its state.
+	DefaultDataDir = "."
+	// DefaultSnapshotBackups is how many older leaderboard snapshots are kept.
+	DefaultSnapshotBackups = 3
+	// DefaultBaselineMaxAge is how old a stored snapshot may be before it is
+	// replaced by a fresh baseline instead of being diffed against.
+	DefaultBaselineMaxAge = 24 * time.Hour
+	// DefaultTimezone is the timezone absolute times are shown in.
+	DefaultTimezone = "UTC"
+	// DefaultDigestInterval is how often accumulated events are posted in
+	// digest mode.
+	DefaultDigestInterval = time.Hour
+)
+
+// DefaultStreakMilestones are the streak lengths that are announced.
+var DefaultStreakMilestones = []int{5, 10, 15, 20, 25}
+
+// Streak timezones. StreakZoneUnlock counts a day as solved in time if both
+// stars came before the next puzzle unlocked, StreakZoneMember if they came
+// before midnight in the member's own timezone, for linked members who have
+// set one.
+const (
+	StreakZoneUnlock = "unlock"
+	StreakZoneMember = "member"
+)
+
+// CommandChannelDM in COMMAND_CHANNELS allows commands in direct messages to
+// the bot.
+const CommandChannelDM = "dm"
+
+// Discussion thread modes. ThreadsOff opens no threads, ThreadsPublic opens a
+// public thread for each puzzle day and ThreadsSpoiler a private one that
+// linked members are added to once they have the day's first star.
+const (
+	ThreadsOff     = "off"
+	ThreadsPublic  = "public"
+	ThreadsSpoiler = "spoiler"
+)
+
+// Notification modes. NotifyImmediate announces the events of every update
+// cycle as soon as they are found, NotifyDigest collects them and posts them
+// every DIGEST_INTERVAL.
+const (
+	NotifyImmediate = "immediate"
+	NotifyDigest    = "digest"
 )
 
 type Config struct {
-	LeaderboardID string
-	SessionCookie string
-	DiscordToken  string
-	ChannelID     string
-	AOCYear       int
+	LeaderboardID   string   `json:"leaderboard_id"`
+	SessionCookie   string   `json:"session_cookie"`
+	DiscordToken    string   `json:"discord_token"`
+	ChannelID       string   `json:"channel_id"`
+	AOCYear         int      `json:"aoc_year"`
+	PollInterval    Duration `json:"poll_interval"`
+	HTTPAddr        string   `json:"http_addr"`
+	ReadyIntervals  int      `json:"ready_intervals"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	DataDir         string   `json:"data_dir"`
+	SnapshotBackups int      `json:"snapshot_backups"`
+	BaselineMaxAge  Duration `json:"baseline_max_age"`
+	NotifyMode      string   `json:"notify_mode"`
+	DigestInterval  Duration `json:"digest_interval"`
+	Locale          string   `json:"locale"`
+	Timezone        string   `json:"timezone"`
+	DayThreads      string   `json:"day_threads"`
+	StreakZone      string   `json:"streak_zone"`
+	// StreakMilestones are the streak lengths to announce. An empty list
+	// turns the announcements off.
+	StreakMilestones []int `json:"streak_milestones"`
+	// StreakNudge is how long before a linked member's streak would break
+	// they get a reminder. Zero turns the reminders off.
+	StreakNudge Duration `json:"streak_nudge"`
+	// AdminRole is a Discord role ID whose members may use !admin, besides
+	// those with the Manage Server permission.
+	AdminRole string `json:"admin_role"`
+	// CommandChannels are the channel IDs, besides the announcement channel,
+	// where commands are answered, including the threads in them. It may
+	// contain CommandChannelDM to answer direct messages too.
+	CommandChannels []string `json:"command_channels"`
+	// Messages overrides message templates by name. It can only be set in
+	// the config file.
+	Messages map[string]string `json:"messages"`
+	// Roles maps Discord roles to what linked members have to do to earn
+	// them. It can only be set in the config file.
+	Roles []roles.Rule `json:"roles"`
+}
+
+// Duration is a time.Duration that is written as a string such as "15m" in
+// the config file.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"15m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
 }
 
-func NewConfig() *Config {
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// NewConfig reads the configuration from the environment. Numbers and
+// durations that cannot be parsed are reported, while an invalid AOC_YEAR
+// falls back to the current year.
+func NewConfig() (*Config, error) {
 	// Default to current year if AOC_YEAR is not set
 	year := time.Now().Year()
 	if yearStr := os.Getenv("AOC_YEAR"); yearStr != "" {
@@ -24,13 +145,368 @@ func NewConfig() *Config {
 		}
 	}
 
-	return &Config{
-		LeaderboardID: os.Getenv("LEADERBOARD_ID"),
-		SessionCookie: os.Getenv("SESSION_COOKIE"),
-		DiscordToken:  os.Getenv("DISCORD_TOKEN"),
-		ChannelID:     os.Getenv("CHANNEL_ID"),
-		AOCYear:       year,
+	var errs []error
+	duration := func(name string) Duration {
+		d, err := envDuration(name)
+		errs = append(errs, err)
+		return Duration{d}
+	}
+	integer := func(name string) int {
+		n, err := envInt(name)
+		errs = append(errs, err)
+		return n
+	}
+	integers := func(name string) []int {
+		ns, err := envInts(name)
+		errs = append(errs, err)
+		return ns
+	}
+
+	cfg := &Config{
+		LeaderboardID:    os.Getenv("LEADERBOARD_ID"),
+		SessionCookie:    os.Getenv("SESSION_COOKIE"),
+		DiscordToken:     os.Getenv("DISCORD_TOKEN"),
+		ChannelID:        os.Getenv("CHANNEL_ID"),
+		AOCYear:          year,
+		PollInterval:     duration("POLL_INTERVAL"),
+		HTTPAddr:         os.Getenv("HTTP_ADDR"),
+		ReadyIntervals:   integer("READY_INTERVALS"),
+		ShutdownTimeout:  duration("SHUTDOWN_TIMEOUT"),
+		DataDir:          os.Getenv("DATA_DIR"),
+		SnapshotBackups:  integer("SNAPSHOT_BACKUPS"),
+		BaselineMaxAge:   duration("BASELINE_MAX_AGE"),
+		NotifyMode:       os.Getenv("NOTIFY_MODE"),
+		DigestInterval:   duration("DIGEST_INTERVAL"),
+		Locale:           os.Getenv("LOCALE"),
+		Timezone:         os.Getenv("TIMEZONE"),
+		DayThreads:       os.Getenv("DAY_THREADS"),
+		StreakZone:       os.Getenv("STREAK_ZONE"),
+		StreakMilestones: integers("STREAK_MILESTONES"),
+		StreakNudge:      duration("STREAK_NUDGE"),
+		AdminRole:        os.Getenv("ADMIN_ROLE"),
+		CommandChannels:  envStrings("COMMAND_CHANNELS"),
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envDuration returns the duration stored in the named environment variable,
+// or zero if it is unset.
+func envDuration(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as \"15m\", got %q", name, value)
+	}
+	return d, nil
+}
+
+// envInt returns the integer stored in the named environment variable, or
+// zero if it is unset.
+func envInt(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", name, value)
+	}
+	return n, nil
+}
+
+// envInts returns the comma separated integers stored in the named
+// environment variable, nil if it is unset, and an empty list if it is
+// "off".
+func envInts(name string) ([]int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, nil
+	}
+	if value == "off" {
+		return []int{}, nil
+	}
+	var ns []int
+	for _, field := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("%s must be comma separated numbers or \"off\", got %q", name, value)
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// envStrings returns the comma separated values stored in the named
+// environment variable, or nil if it is unset.
+func envStrings(name string) []string {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	var values []string
+	for _, field := range strings.Split(value, ",") {
+		values = append(values, strings.TrimSpace(field))
+	}
+	return values
+}
+
+// Load reads the configuration from the environment and, when CONFIG_FILE is
+// set, overlays any values present in that JSON file.
+func Load() (*Config, error) {
+	cfg, err := NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSecretFiles(); err != nil {
+		return nil, err
+	}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
+	cfg.applyDefaults()
+	return cfg, nil
+}
+
+// applyDefaults fills in optional settings that were left unset.
+func (c *Config) applyDefaults() {
+	if c.PollInterval.Duration == 0 {
+		c.PollInterval.Duration = DefaultPollInterval
+	}
+	if c.ReadyIntervals == 0 {
+		c.ReadyIntervals = DefaultReadyIntervals
+	}
+	if c.ShutdownTimeout.Duration == 0 {
+		c.ShutdownTimeout.Duration = DefaultShutdownTimeout
+	}
+	if c.DataDir == "" {
+		c.DataDir = DefaultDataDir
+	}
+	if c.SnapshotBackups == 0 {
+		c.SnapshotBackups = DefaultSnapshotBackups
+	}
+	if c.BaselineMaxAge.Duration == 0 {
+		c.BaselineMaxAge.Duration = DefaultBaselineMaxAge
+	}
+	if c.NotifyMode == "" {
+		c.NotifyMode = NotifyImmediate
+	}
+	if c.DigestInterval.Duration == 0 {
+		c.DigestInterval.Duration = DefaultDigestInterval
+	}
+	if c.Locale == "" {
+		c.Locale = messages.DefaultLocale
+	}
+	if c.Timezone == "" {
+		c.Timezone = DefaultTimezone
+	}
+	if c.DayThreads == "" {
+		c.DayThreads = ThreadsOff
+	}
+	if c.StreakZone == "" {
+		c.StreakZone = StreakZoneUnlock
+	}
+	if c.StreakMilestones == nil {
+		c.StreakMilestones = append([]int(nil), DefaultStreakMilestones...)
+	}
+}
+
+// loadSecretFiles fills in secrets from the *_FILE variants of their
+// environment variables, as used by Docker and Kubernetes secrets. A plain
+// environment variable takes precedence over its *_FILE variant.
+func (c *Config) loadSecretFiles() error {
+	secrets := []struct {
+		name  string
+		value *string
+	}{
+		{"SESSION_COOKIE", &c.SessionCookie},
+		{"DISCORD_TOKEN", &c.DiscordToken},
 	}
+	for _, secret := range secrets {
+		path := os.Getenv(secret.name + "_FILE")
+		if *secret.value != "" || path == "" {
+			continue
+		}
+		value, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s_FILE: %w", secret.name, err)
+		}
+		*secret.value = value
+	}
+	return nil
+}
+
+// resolveSecrets replaces secret references of the form "file:<path>" or
+// "env:<NAME>", which is how a config file points at a secret without
+// containing it.
+func (c *Config) resolveSecrets() error {
+	for _, secret := range []*string{&c.SessionCookie, &c.DiscordToken} {
+		value, err := resolveSecret(*secret)
+		if err != nil {
+			return err
+		}
+		*secret = value
+	}
+	return nil
+}
+
+func resolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "file:"):
+		value, err := readSecretFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("error resolving secret reference: %w", err)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value := os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("error resolving secret reference: environment variable %s is not set", name)
+		}
+		return value, nil
+	}
+	return ref, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	return nil
+}
+
+// Diff returns a human readable description of every setting that differs
+// between c and other. Secret values are never included in the output.
+func (c *Config) Diff(other *Config) []string {
+	var changes []string
+	if c.LeaderboardID != other.LeaderboardID {
+		changes = append(changes, fmt.Sprintf("LEADERBOARD_ID: %s -> %s", c.LeaderboardID, other.LeaderboardID))
+	}
+	if c.SessionCookie != other.SessionCookie {
+		changes = append(changes, "SESSION_COOKIE changed")
+	}
+	if c.DiscordToken != other.DiscordToken {
+		changes = append(changes, "DISCORD_TOKEN changed")
+	}
+	if c.ChannelID != other.ChannelID {
+		changes = append(changes, fmt.Sprintf("CHANNEL_ID: %s -> %s", c.ChannelID, other.ChannelID))
+	}
+	if c.AOCYear != other.AOCYear {
+		changes = append(changes, fmt.Sprintf("AOC_YEAR: %d -> %d", c.AOCYear, other.AOCYear))
+	}
+	if c.PollInterval != other.PollInterval {
+		changes = append(changes, fmt.Sprintf("POLL_INTERVAL: %s -> %s", c.PollInterval, other.PollInterval))
+	}
+	if c.HTTPAddr != other.HTTPAddr {
+		changes = append(changes, fmt.Sprintf("HTTP_ADDR: %s -> %s", c.HTTPAddr, other.HTTPAddr))
+	}
+	if c.ReadyIntervals != other.ReadyIntervals {
+		changes = append(changes, fmt.Sprintf("READY_INTERVALS: %d -> %d", c.ReadyIntervals, other.ReadyIntervals))
+	}
+	if c.ShutdownTimeout != other.ShutdownTimeout {
+		changes = append(changes, fmt.Sprintf("SHUTDOWN_TIMEOUT: %s -> %s", c.ShutdownTimeout, other.ShutdownTimeout))
+	}
+	if c.DataDir != other.DataDir {
+		changes = append(changes, fmt.Sprintf("DATA_DIR: %s -> %s", c.DataDir, other.DataDir))
+	}
+	if c.SnapshotBackups != other.SnapshotBackups {
+		changes = append(changes, fmt.Sprintf("SNAPSHOT_BACKUPS: %d -> %d", c.SnapshotBackups, other.SnapshotBackups))
+	}
+	if c.BaselineMaxAge != other.BaselineMaxAge {
+		changes = append(changes, fmt.Sprintf("BASELINE_MAX_AGE: %s -> %s", c.BaselineMaxAge, other.BaselineMaxAge))
+	}
+	if c.NotifyMode != other.NotifyMode {
+		changes = append(changes, fmt.Sprintf("NOTIFY_MODE: %s -> %s", c.NotifyMode, other.NotifyMode))
+	}
+	if c.DigestInterval != other.DigestInterval {
+		changes = append(changes, fmt.Sprintf("DIGEST_INTERVAL: %s -> %s", c.DigestInterval, other.DigestInterval))
+	}
+	if c.Locale != other.Locale {
+		changes = append(changes, fmt.Sprintf("LOCALE: %s -> %s", c.Locale, other.Locale))
+	}
+	if c.Timezone != other.Timezone {
+		changes = append(changes, fmt.Sprintf("TIMEZONE: %s -> %s", c.Timezone, other.Timezone))
+	}
+	if c.DayThreads != other.DayThreads {
+		changes = append(changes, fmt.Sprintf("DAY_THREADS: %s -> %s", c.DayThreads, other.DayThreads))
+	}
+	if c.StreakZone != other.StreakZone {
+		changes = append(changes, fmt.Sprintf("STREAK_ZONE: %s -> %s", c.StreakZone, other.StreakZone))
+	}
+	if !reflect.DeepEqual(c.StreakMilestones, other.StreakMilestones) {
+		changes = append(changes, fmt.Sprintf("STREAK_MILESTONES: %v -> %v", c.StreakMilestones, other.StreakMilestones))
+	}
+	if c.StreakNudge != other.StreakNudge {
+		changes = append(changes, fmt.Sprintf("STREAK_NUDGE: %s -> %s", c.StreakNudge, other.StreakNudge))
+	}
+	if c.AdminRole != other.AdminRole {
+		changes = append(changes, fmt.Sprintf("ADMIN_ROLE: %s -> %s", c.AdminRole, other.AdminRole))
+	}
+	if !reflect.DeepEqual(c.CommandChannels, other.CommandChannels) {
+		changes = append(changes, fmt.Sprintf("COMMAND_CHANNELS: %v -> %v", c.CommandChannels, other.CommandChannels))
+	}
+	if !reflect.DeepEqual(c.Messages, other.Messages) {
+		changes = append(changes, "message templates changed")
+	}
+	if !reflect.DeepEqual(c.Roles, other.Roles) {
+		changes = append(changes, "role rules changed")
+	}
+	return changes
+}
+
+// Location returns the configured timezone. It falls back to UTC, which only
+// happens for a configuration that has not been validated.
+func (c *Config) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// String returns the effective configuration with secrets redacted, so that
+// it is safe to log.
+func (c *Config) String() string {
+	return fmt.Sprintf("LEADERBOARD_ID=%s SESSION_COOKIE=%s DISCORD_TOKEN=%s CHANNEL_ID=%s AOC_YEAR=%d POLL_INTERVAL=%s HTTP_ADDR=%s READY_INTERVALS=%d SHUTDOWN_TIMEOUT=%s DATA_DIR=%s SNAPSHOT_BACKUPS=%d BASELINE_MAX_AGE=%s NOTIFY_MODE=%s DIGEST_INTERVAL=%s LOCALE=%s TIMEZONE=%s DAY_THREADS=%s STREAK_ZONE=%s STREAK_MILESTONES=%v STREAK_NUDGE=%s ADMIN_ROLE=%s COMMAND_CHANNELS=%v MESSAGES=%d overridden ROLES=%d rules",
+		c.LeaderboardID, redact(c.SessionCookie), redact(c.DiscordToken), c.ChannelID, c.AOCYear,
+		c.PollInterval, c.HTTPAddr, c.ReadyIntervals, c.ShutdownTimeout, c.DataDir, c.SnapshotBackups,
+		c.BaselineMaxAge, c.NotifyMode, c.DigestInterval, c.Locale, c.Timezone, c.DayThreads,
+		c.StreakZone, c.StreakMilestones, c.StreakNudge, c.AdminRole, c.CommandChannels, len(c.Messages), len(c.Roles))
+}
+
+// GoString keeps secrets out of %#v output as well.
+func (c *Config) GoString() string {
+	return c.String()
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return "<unset>"
+	}
+	return "<redacted>"
 }
 
 // Validate checks that all required configuration values are present.
@@ -50,5 +526,58 @@ func (c *Config) Validate() error {
 	if c.AOCYear < 2015 {
 		return fmt.Errorf("AOC_YEAR must be 2015 or later (Advent of Code started in 2015)")
 	}
+	if c.PollInterval.Duration != 0 && c.PollInterval.Duration < DefaultPollInterval {
+		return fmt.Errorf("POLL_INTERVAL must be at least %s (Advent of Code asks not to poll more often)", DefaultPollInterval)
+	}
+	if c.ReadyIntervals < 0 {
+		return fmt.Errorf("READY_INTERVALS must not be negative")
+	}
+	if c.ShutdownTimeout.Duration < 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must not be negative")
+	}
+	if c.SnapshotBackups < 0 {
+		return fmt.Errorf("SNAPSHOT_BACKUPS must not be negative")
+	}
+	if c.BaselineMaxAge.Duration < 0 {
+		return fmt.Errorf("BASELINE_MAX_AGE must not be negative")
+	}
+	if c.NotifyMode != "" && c.NotifyMode != NotifyImmediate && c.NotifyMode != NotifyDigest {
+		return fmt.Errorf("NOTIFY_MODE must be %q or %q", NotifyImmediate, NotifyDigest)
+	}
+	if c.DigestInterval.Duration < 0 {
+		return fmt.Errorf("DIGEST_INTERVAL must not be negative")
+	}
+	switch c.DayThreads {
+	case "", ThreadsOff, ThreadsPublic, ThreadsSpoiler:
+	default:
+		return fmt.Errorf("DAY_THREADS must be %q, %q or %q", ThreadsOff, ThreadsPublic, ThreadsSpoiler)
+	}
+	switch c.StreakZone {
+	case "", StreakZoneUnlock, StreakZoneMember:
+	default:
+		return fmt.Errorf("STREAK_ZONE must be %q or %q", StreakZoneUnlock, StreakZoneMember)
+	}
+	for _, milestone := range c.StreakMilestones {
+		if milestone <= 0 {
+			return fmt.Errorf("STREAK_MILESTONES must be positive numbers of days")
+		}
+	}
+	if c.StreakNudge.Duration < 0 {
+		return fmt.Errorf("STREAK_NUDGE must not be negative")
+	}
+	for _, channelID := range c.CommandChannels {
+		if channelID == "" {
+			return fmt.Errorf("COMMAND_CHANNELS must be channel IDs or %q, separated by commas", CommandChannelDM)
+		}
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("TIMEZONE must be an IANA timezone such as Europe/Paris: %w", err)
+	}
+	if _, err := messages.New(c.Locale, c.Messages); err != nil {
+		return fmt.Errorf("invalid messages: %w", err)
+	}
+	if err := roles.Validate(c.Roles, c.AOCYear); err != nil {
+		return fmt.Errorf("invalid roles: %w", err)
+	}
 	return nil
 }
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/PaytonWebber/aoc-discord-bot/internal/roles"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,7 +21,8 @@ func TestNewConfig(t *testing.T) {
 		t.Setenv("AOC_YEAR", "2023")
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, "prod-leaderboard", cfg.LeaderboardID, "LeaderboardID should match")
@@ -34,7 +39,8 @@ func TestNewConfig(t *testing.T) {
 		// DISCORD_TOKEN and CHANNEL_ID are not set
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, "prod-leaderboard", cfg.LeaderboardID, "LeaderboardID should match")
@@ -48,7 +54,8 @@ func TestNewConfig(t *testing.T) {
 		// No environment variables are set
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, "", cfg.LeaderboardID, "LeaderboardID should be empty")
@@ -63,7 +70,8 @@ func TestNewConfig(t *testing.T) {
 		t.Setenv("AOC_YEAR", "2022")
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, 2022, cfg.AOCYear, "AOCYear should be 2022")
@@ -74,7 +82,8 @@ func TestNewConfig(t *testing.T) {
 		t.Setenv("AOC_YEAR", "not-a-number")
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, time.Now().Year(), cfg.AOCYear, "AOCYear should default to current year when invalid")
@@ -85,7 +94,8 @@ func TestNewConfig(t *testing.T) {
 		t.Setenv("AOC_YEAR", "2014")
 
 		// Call NewConfig
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Assertions
 		assert.Equal(t, time.Now().Year(), cfg.AOCYear, "AOCYear should default to current year when below 2015")
@@ -102,7 +112,8 @@ func TestConfigStruct(t *testing.T) {
 		t.Setenv("AOC_YEAR", "2024")
 
 		// Initialize Config
-		cfg := NewConfig()
+		cfg, err := NewConfig()
+		assert.NoError(t, err, "NewConfig should not return an error")
 
 		// Directly test struct fields
 		expected := &Config{
@@ -197,3 +208,386 @@ func TestValidate(t *testing.T) {
 		assert.Contains(t, err.Error(), "AOC_YEAR", "Error should mention AOC_YEAR")
 	})
 }
+
+func TestLoad(t *testing.T) {
+	t.Run("Without Config File", func(t *testing.T) {
+		t.Setenv("LEADERBOARD_ID", "env-leaderboard")
+		t.Setenv("CHANNEL_ID", "env-channel")
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error without a config file")
+		assert.Equal(t, "env-leaderboard", cfg.LeaderboardID, "LeaderboardID should come from the environment")
+		assert.Equal(t, "env-channel", cfg.ChannelID, "ChannelID should come from the environment")
+	})
+
+	t.Run("Config File Overrides Environment", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(`{"channel_id": "file-channel", "aoc_year": 2021}`), 0o600)
+		assert.NoError(t, err)
+
+		t.Setenv("LEADERBOARD_ID", "env-leaderboard")
+		t.Setenv("CHANNEL_ID", "env-channel")
+		t.Setenv("CONFIG_FILE", path)
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error for a valid config file")
+		assert.Equal(t, "env-leaderboard", cfg.LeaderboardID, "LeaderboardID should be kept from the environment")
+		assert.Equal(t, "file-channel", cfg.ChannelID, "ChannelID should be overridden by the config file")
+		assert.Equal(t, 2021, cfg.AOCYear, "AOCYear should be overridden by the config file")
+	})
+
+	t.Run("Invalid Config File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(`not json`), 0o600)
+		assert.NoError(t, err)
+
+		t.Setenv("CONFIG_FILE", path)
+
+		cfg, err := Load()
+
+		assert.Error(t, err, "Load should fail for an invalid config file")
+		assert.Nil(t, cfg, "Config should be nil when loading fails")
+	})
+
+	t.Run("Missing Config File", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+
+		_, err := Load()
+
+		assert.Error(t, err, "Load should fail when the config file does not exist")
+	})
+}
+
+func TestDiff(t *testing.T) {
+	current := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "old-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "old-channel",
+		AOCYear:       2023,
+	}
+
+	t.Run("No Changes", func(t *testing.T) {
+		next := *current
+
+		assert.Empty(t, current.Diff(&next), "Identical configs should have no changes")
+	})
+
+	t.Run("Changes Are Reported Without Secrets", func(t *testing.T) {
+		next := *current
+		next.SessionCookie = "new-cookie"
+		next.ChannelID = "new-channel"
+		next.AOCYear = 2024
+
+		changes := current.Diff(&next)
+
+		assert.Equal(t, []string{
+			"SESSION_COOKIE changed",
+			"CHANNEL_ID: old-channel -> new-channel",
+			"AOC_YEAR: 2023 -> 2024",
+		}, changes, "Changes should list every modified setting")
+		for _, change := range changes {
+			assert.NotContains(t, change, "new-cookie", "Secrets should never be included in the diff")
+		}
+	})
+}
+
+func TestLoadSecrets(t *testing.T) {
+	t.Run("Secrets From Files", func(t *testing.T) {
+		dir := t.TempDir()
+		cookiePath := filepath.Join(dir, "session_cookie")
+		tokenPath := filepath.Join(dir, "discord_token")
+		assert.NoError(t, os.WriteFile(cookiePath, []byte("file-session-cookie\n"), 0o600))
+		assert.NoError(t, os.WriteFile(tokenPath, []byte("file-discord-token\n"), 0o600))
+
+		t.Setenv("SESSION_COOKIE_FILE", cookiePath)
+		t.Setenv("DISCORD_TOKEN_FILE", tokenPath)
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, "file-session-cookie", cfg.SessionCookie, "SessionCookie should be read from the file")
+		assert.Equal(t, "file-discord-token", cfg.DiscordToken, "DiscordToken should be read from the file")
+	})
+
+	t.Run("Environment Variable Takes Precedence Over File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "session_cookie")
+		assert.NoError(t, os.WriteFile(path, []byte("file-session-cookie"), 0o600))
+
+		t.Setenv("SESSION_COOKIE", "env-session-cookie")
+		t.Setenv("SESSION_COOKIE_FILE", path)
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, "env-session-cookie", cfg.SessionCookie, "SessionCookie should come from the environment")
+	})
+
+	t.Run("Missing Secret File", func(t *testing.T) {
+		t.Setenv("SESSION_COOKIE_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := Load()
+
+		assert.Error(t, err, "Load should fail when the secret file does not exist")
+		assert.Contains(t, err.Error(), "SESSION_COOKIE_FILE", "Error should mention SESSION_COOKIE_FILE")
+	})
+
+	t.Run("Secret References In Config File", func(t *testing.T) {
+		dir := t.TempDir()
+		cookiePath := filepath.Join(dir, "session_cookie")
+		configPath := filepath.Join(dir, "config.json")
+		assert.NoError(t, os.WriteFile(cookiePath, []byte("ref-session-cookie"), 0o600))
+		configJSON := `{"session_cookie": "file:` + cookiePath + `", "discord_token": "env:BOT_TOKEN"}`
+		assert.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0o600))
+
+		t.Setenv("CONFIG_FILE", configPath)
+		t.Setenv("BOT_TOKEN", "ref-discord-token")
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, "ref-session-cookie", cfg.SessionCookie, "SessionCookie should be resolved from the file reference")
+		assert.Equal(t, "ref-discord-token", cfg.DiscordToken, "DiscordToken should be resolved from the env reference")
+	})
+
+	t.Run("Unresolvable Secret Reference", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "config.json")
+		assert.NoError(t, os.WriteFile(configPath, []byte(`{"discord_token": "env:MISSING_BOT_TOKEN"}`), 0o600))
+
+		t.Setenv("CONFIG_FILE", configPath)
+
+		_, err := Load()
+
+		assert.Error(t, err, "Load should fail when a secret reference cannot be resolved")
+	})
+}
+
+func TestConfigString(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "secret-cookie",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+	}
+
+	for _, output := range []string{cfg.String(), fmt.Sprintf("%v", cfg), fmt.Sprintf("%#v", cfg)} {
+		assert.NotContains(t, output, "secret-cookie", "Secrets should be redacted")
+		assert.Contains(t, output, "SESSION_COOKIE=<redacted>", "Set secrets should be shown as redacted")
+		assert.Contains(t, output, "DISCORD_TOKEN=<unset>", "Missing secrets should be shown as unset")
+		assert.Contains(t, output, "LEADERBOARD_ID=test-leaderboard", "Non-secret values should be shown")
+		assert.Contains(t, output, "AOC_YEAR=2024", "Non-secret values should be shown")
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Run("Defaults Applied", func(t *testing.T) {
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, DefaultPollInterval, cfg.PollInterval.Duration, "PollInterval should default to 15 minutes")
+		assert.Equal(t, DefaultReadyIntervals, cfg.ReadyIntervals, "ReadyIntervals should default to 3")
+		assert.Equal(t, "", cfg.HTTPAddr, "HTTPAddr should default to disabled")
+		assert.Equal(t, DefaultBaselineMaxAge, cfg.BaselineMaxAge.Duration, "BaselineMaxAge should default to 24 hours")
+	})
+
+	t.Run("Values From Environment", func(t *testing.T) {
+		t.Setenv("POLL_INTERVAL", "30m")
+		t.Setenv("READY_INTERVALS", "5")
+		t.Setenv("HTTP_ADDR", ":8080")
+		t.Setenv("BASELINE_MAX_AGE", "48h")
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, 30*time.Minute, cfg.PollInterval.Duration, "PollInterval should match")
+		assert.Equal(t, 5, cfg.ReadyIntervals, "ReadyIntervals should match")
+		assert.Equal(t, ":8080", cfg.HTTPAddr, "HTTPAddr should match")
+		assert.Equal(t, 48*time.Hour, cfg.BaselineMaxAge.Duration, "BaselineMaxAge should match")
+	})
+
+	t.Run("Duration From Config File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"poll_interval": "1h"}`), 0o600))
+		t.Setenv("CONFIG_FILE", path)
+
+		cfg, err := Load()
+
+		assert.NoError(t, err, "Load should not return an error")
+		assert.Equal(t, time.Hour, cfg.PollInterval.Duration, "PollInterval should be parsed from the config file")
+	})
+}
+
+func TestValidatePollInterval(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		PollInterval:  Duration{time.Minute},
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for a poll interval below 15 minutes")
+	assert.Contains(t, err.Error(), "POLL_INTERVAL", "Error should mention POLL_INTERVAL")
+}
+
+func TestValidateNotifyMode(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		NotifyMode:    "weekly",
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for an unknown notification mode")
+	assert.Contains(t, err.Error(), "NOTIFY_MODE", "Error should mention NOTIFY_MODE")
+
+	cfg.NotifyMode = NotifyDigest
+	assert.NoError(t, cfg.Validate(), "Digest mode should be valid")
+}
+
+func TestValidateDayThreads(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		DayThreads:    "forum",
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for an unknown thread mode")
+	assert.Contains(t, err.Error(), "DAY_THREADS", "Error should mention DAY_THREADS")
+
+	cfg.DayThreads = ThreadsSpoiler
+	assert.NoError(t, cfg.Validate(), "Spoiler threads should be valid")
+}
+
+func TestValidateStreaks(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		StreakZone:    "local",
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for an unknown streak timezone")
+	assert.Contains(t, err.Error(), "STREAK_ZONE", "Error should mention STREAK_ZONE")
+
+	cfg.StreakZone = StreakZoneMember
+	cfg.StreakMilestones = []int{5, 0}
+	err = cfg.Validate()
+	assert.Error(t, err, "Should return error for a milestone of zero days")
+	assert.Contains(t, err.Error(), "STREAK_MILESTONES", "Error should mention STREAK_MILESTONES")
+
+	cfg.StreakMilestones = []int{}
+	assert.NoError(t, cfg.Validate(), "Announcements should be able to be turned off")
+}
+
+func TestStreakMilestonesFromEnvironment(t *testing.T) {
+	t.Setenv("STREAK_MILESTONES", "3, 7")
+	cfg, err := Load()
+	assert.NoError(t, err, "Load should not return an error")
+	assert.Equal(t, []int{3, 7}, cfg.StreakMilestones, "Milestones should be parsed")
+
+	t.Setenv("STREAK_MILESTONES", "off")
+	cfg, err = Load()
+	assert.NoError(t, err, "Load should not return an error")
+	assert.Empty(t, cfg.StreakMilestones, "off should turn the announcements off")
+
+	t.Setenv("STREAK_MILESTONES", "")
+	cfg, err = Load()
+	assert.NoError(t, err, "Load should not return an error")
+	assert.Equal(t, DefaultStreakMilestones, cfg.StreakMilestones, "Milestones should default")
+}
+
+func TestMalformedEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"POLL_INTERVAL", "15"},
+		{"STREAK_NUDGE", "two hours"},
+		{"READY_INTERVALS", "three"},
+		{"SNAPSHOT_BACKUPS", "3.5"},
+		{"STREAK_MILESTONES", "5,ten"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+
+			cfg, err := Load()
+
+			assert.Error(t, err, "Load should fail for a malformed value")
+			assert.Nil(t, cfg, "Config should be nil when loading fails")
+			if err != nil {
+				assert.Contains(t, err.Error(), tt.name, "Error should name the variable")
+			}
+		})
+	}
+}
+
+func TestCommandChannels(t *testing.T) {
+	t.Setenv("COMMAND_CHANNELS", "123, dm")
+	cfg, err := Load()
+	assert.NoError(t, err, "Load should not return an error")
+	assert.Equal(t, []string{"123", CommandChannelDM}, cfg.CommandChannels, "Channels should be parsed")
+
+	cfg = &Config{
+		LeaderboardID:   "test-leaderboard",
+		SessionCookie:   "test-cookie",
+		DiscordToken:    "test-token",
+		ChannelID:       "test-channel",
+		AOCYear:         2024,
+		CommandChannels: []string{"123", ""},
+	}
+	err = cfg.Validate()
+	assert.Error(t, err, "Should return error for an empty channel")
+	assert.Contains(t, err.Error(), "COMMAND_CHANNELS", "Error should mention COMMAND_CHANNELS")
+}
+
+func TestValidateMessages(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		Messages:      map[string]string{"new_star": "{{.Nickname}} got a star"},
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for a template that does not render")
+	assert.Contains(t, err.Error(), "new_star", "Error should name the template")
+}
+
+func TestValidateRoles(t *testing.T) {
+	cfg := &Config{
+		LeaderboardID: "test-leaderboard",
+		SessionCookie: "test-cookie",
+		DiscordToken:  "test-token",
+		ChannelID:     "test-channel",
+		AOCYear:       2024,
+		Roles:         []roles.Rule{{RoleID: "123", Kind: roles.Stars}},
+	}
+
+	err := cfg.Validate()
+
+	assert.Error(t, err, "Should return error for a star rule without a star count")
+	assert.Contains(t, err.Error(), "roles", "Error should mention roles")
+}
@@ -0,0 +1,158 @@
+// Package cooldown limits how often chat commands can be used and merges
+// identical requests that arrive while one is being answered.
+package cooldown
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit allows Count uses in any Window. A zero Count means no limit.
+type Limit struct {
+	Count  int
+	Window time.Duration
+}
+
+// Rule is the limit of one command, counted separately for each user and for
+// each channel. A use has to fit in both.
+type Rule struct {
+	PerUser    Limit
+	PerChannel Limit
+}
+
+// Decision is the outcome of asking to use a command. When it is not
+// allowed, Wait is how long until it will be, and Notify is set the first
+// time a use is refused, so that the user is told once rather than on every
+// attempt.
+type Decision struct {
+	Allowed bool
+	Wait    time.Duration
+	Notify  bool
+}
+
+type bucketKey struct {
+	command string
+	scope   string
+	id      string
+}
+
+type bucket struct {
+	uses     []time.Time
+	window   time.Duration
+	notified bool
+}
+
+// Limiter keeps the recent uses of every command in sliding windows. A
+// bucket is dropped once its window is empty, so only users and channels
+// that used a command recently take up memory. It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[bucketKey]*bucket)}
+}
+
+// Allow decides whether a user may use a command in a channel at now, and
+// counts the use if so.
+func (l *Limiter) Allow(command, userID, channelID string, rule Rule, now time.Time) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+
+	checks := []struct {
+		key   bucketKey
+		limit Limit
+	}{
+		{bucketKey{command, "user", userID}, rule.PerUser},
+		{bucketKey{command, "channel", channelID}, rule.PerChannel},
+	}
+
+	var refused []*bucket
+	var wait time.Duration
+	for _, check := range checks {
+		b, ok := l.buckets[check.key]
+		if check.limit.Count <= 0 || !ok {
+			continue
+		}
+		if len(b.uses) >= check.limit.Count {
+			refused = append(refused, b)
+			if w := b.uses[0].Add(check.limit.Window).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+	if len(refused) > 0 {
+		notify := false
+		for _, b := range refused {
+			if !b.notified {
+				b.notified = true
+				notify = true
+			}
+		}
+		return Decision{Wait: wait, Notify: notify}
+	}
+
+	for _, check := range checks {
+		if check.limit.Count <= 0 {
+			continue
+		}
+		b, ok := l.buckets[check.key]
+		if !ok {
+			b = &bucket{}
+			l.buckets[check.key] = b
+		}
+		b.uses = append(b.uses, now)
+		b.window = check.limit.Window
+		b.notified = false
+	}
+	return Decision{Allowed: true}
+}
+
+// prune drops the uses that fell out of their window, and the buckets left
+// empty. The caller must hold l.mu.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		expired := 0
+		for expired < len(b.uses) && !now.Before(b.uses[expired].Add(b.window)) {
+			expired++
+		}
+		b.uses = b.uses[expired:]
+		if len(b.uses) == 0 {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// InFlight tracks the requests being answered, so that an identical request
+// arriving in the meantime is answered by the same response. It is safe for
+// concurrent use.
+type InFlight struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{keys: make(map[string]bool)}
+}
+
+// Start marks a request as being answered. It returns false if an identical
+// one already is, in which case the caller should not answer it again.
+// Every successful Start must be followed by Done.
+func (f *InFlight) Start(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.keys[key] {
+		return false
+	}
+	f.keys[key] = true
+	return true
+}
+
+// Done marks a request as answered.
+func (f *InFlight) Done(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.keys, key)
+}
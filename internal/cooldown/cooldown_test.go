@@ -0,0 +1,87 @@
+package cooldown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterPerUser(t *testing.T) {
+	limiter := NewLimiter()
+	rule := Rule{PerUser: Limit{Count: 2, Window: time.Minute}}
+	start := time.Unix(0, 0)
+
+	assert.True(t, limiter.Allow("!stars", "alice", "general", rule, start).Allowed, "The first use should be allowed")
+	assert.True(t, limiter.Allow("!stars", "alice", "general", rule, start.Add(10*time.Second)).Allowed, "The second use should be allowed")
+
+	decision := limiter.Allow("!stars", "alice", "general", rule, start.Add(20*time.Second))
+	assert.False(t, decision.Allowed, "A third use within the window should be refused")
+	assert.Equal(t, 40*time.Second, decision.Wait, "Wait should be until the oldest use leaves the window")
+	assert.True(t, decision.Notify, "The first refusal should be reported")
+	assert.False(t, limiter.Allow("!stars", "alice", "general", rule, start.Add(30*time.Second)).Notify, "Later refusals should not be reported again")
+
+	assert.True(t, limiter.Allow("!stars", "bob", "general", rule, start.Add(30*time.Second)).Allowed, "Other users should have their own bucket")
+	assert.True(t, limiter.Allow("!times", "alice", "general", rule, start.Add(30*time.Second)).Allowed, "Other commands should have their own bucket")
+	assert.True(t, limiter.Allow("!stars", "alice", "general", rule, start.Add(time.Minute)).Allowed, "Uses should be allowed again once the window has passed")
+}
+
+func TestLimiterPerChannel(t *testing.T) {
+	limiter := NewLimiter()
+	rule := Rule{PerChannel: Limit{Count: 1, Window: time.Minute}}
+	start := time.Unix(0, 0)
+
+	assert.True(t, limiter.Allow("!help", "alice", "general", rule, start).Allowed, "The first use should be allowed")
+	assert.False(t, limiter.Allow("!help", "bob", "general", rule, start.Add(time.Second)).Allowed, "The channel's limit should apply to every user")
+	assert.True(t, limiter.Allow("!help", "bob", "random", rule, start.Add(time.Second)).Allowed, "Other channels should have their own bucket")
+}
+
+func TestLimiterRefusedUsesAreNotCounted(t *testing.T) {
+	limiter := NewLimiter()
+	rule := Rule{
+		PerUser:    Limit{Count: 5, Window: time.Minute},
+		PerChannel: Limit{Count: 1, Window: time.Minute},
+	}
+	start := time.Unix(0, 0)
+
+	limiter.Allow("!stars", "alice", "general", rule, start)
+	for i := 0; i < 10; i++ {
+		limiter.Allow("!stars", "bob", "general", rule, start.Add(time.Second))
+	}
+
+	assert.True(t, limiter.Allow("!stars", "bob", "random", rule, start.Add(2*time.Second)).Allowed, "Refused uses should not count against the user")
+}
+
+func TestLimiterWithoutLimits(t *testing.T) {
+	limiter := NewLimiter()
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.Allow("!admin", "alice", "general", Rule{}, time.Unix(0, 0)).Allowed, "A command without limits should always be allowed")
+	}
+}
+
+func TestLimiterDropsEmptyBuckets(t *testing.T) {
+	limiter := NewLimiter()
+	rule := Rule{
+		PerUser:    Limit{Count: 1, Window: time.Minute},
+		PerChannel: Limit{Count: 5, Window: time.Minute},
+	}
+	start := time.Unix(0, 0)
+
+	limiter.Allow("!stars", "alice", "general", rule, start)
+	limiter.Allow("!stars", "bob", "general", rule, start)
+	assert.Len(t, limiter.buckets, 3, "Every user and channel should have a bucket")
+
+	limiter.Allow("!help", "carol", "random", Rule{}, start.Add(time.Minute))
+	assert.Empty(t, limiter.buckets, "Buckets should be dropped once their window is empty")
+}
+
+func TestInFlight(t *testing.T) {
+	inFlight := NewInFlight()
+
+	assert.True(t, inFlight.Start("general !stars"), "The first request should be answered")
+	assert.False(t, inFlight.Start("general !stars"), "An identical request should be merged into it")
+	assert.True(t, inFlight.Start("general !times"), "Other requests should be answered")
+
+	inFlight.Done("general !stars")
+	assert.True(t, inFlight.Start("general !stars"), "A request should be answered again once the first one is done")
+}
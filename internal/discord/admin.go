@@ -0,0 +1,279 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/admin"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/profiles"
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminPermissions are the permissions that make a user an admin of the bot.
+const adminPermissions = discordgo.PermissionAdministrator | discordgo.PermissionManageServer
+
+// adminCommand runs an admin subcommand:
+//
+//	!admin refresh                  check for updates, ignoring the !update cooldown
+//	!admin pause | resume           stop or restart announcements
+//	!admin channel <#channel>       send announcements to another channel
+//	!admin alias <member> [alias]   show a member under another name, or their own again
+//	!admin link <@user> <member>    link a user to a member, taking it from whoever had it
+//	!admin unlink <@user>           remove a user's link
+//	!admin baseline                 post the leaderboard summary again
+//	!admin status                   show the bot's status and last errors
+func (bh *BotHandler) adminCommand(req request) {
+	if !bh.isAdmin(req) {
+		bh.reply(req, messages.AdminOnly, messages.Data{})
+		return
+	}
+	if len(req.args) == 0 || bh.Admin == nil {
+		bh.reply(req, messages.AdminUsage, messages.Data{})
+		return
+	}
+
+	log.Printf("Admin command %q from %s", strings.Join(req.args, " "), req.userID)
+	args := req.args[1:]
+	switch strings.ToLower(req.args[0]) {
+	case "refresh":
+		bh.refreshCommand(req)
+	case "pause":
+		bh.setPaused(req, true)
+	case "resume":
+		bh.setPaused(req, false)
+	case "channel":
+		if len(args) != 1 {
+			bh.reply(req, messages.AdminUsage, messages.Data{})
+			return
+		}
+		bh.channelCommand(req, args[0])
+	case "alias":
+		if len(args) == 0 {
+			bh.reply(req, messages.AdminUsage, messages.Data{})
+			return
+		}
+		bh.aliasCommand(req, args[0], strings.Join(args[1:], " "))
+	case "link":
+		if len(args) < 2 || bh.Profiles == nil {
+			bh.reply(req, messages.AdminUsage, messages.Data{})
+			return
+		}
+		bh.adminLinkCommand(req, mentionedUser(args[0]), strings.Join(args[1:], " "))
+	case "unlink":
+		if len(args) != 1 || bh.Profiles == nil {
+			bh.reply(req, messages.AdminUsage, messages.Data{})
+			return
+		}
+		bh.adminUnlinkCommand(req, mentionedUser(args[0]))
+	case "baseline":
+		current := bh.Tracker.Snapshot().Current
+		if current == nil {
+			bh.reply(req, messages.NoUpdates, messages.Data{})
+			return
+		}
+		bh.SendChannelMessage(bh.AnnouncementChannel(), bh.format().FormatBaseline(current))
+	case "status":
+		bh.statusCommand(req)
+	default:
+		bh.reply(req, messages.AdminUsage, messages.Data{})
+	}
+}
+
+// isAdmin reports whether the user may use admin commands: they have the
+// Manage Server permission in the channel, or the configured ADMIN_ROLE.
+func (bh *BotHandler) isAdmin(req request) bool {
+	permissions, err := bh.Session.UserChannelPermissions(req.userID, req.channelID)
+	if err != nil {
+		log.Printf("error getting the permissions of %s: %v", req.userID, err)
+	} else if permissions&adminPermissions != 0 {
+		return true
+	}
+
+	role := bh.config().AdminRole
+	if role == "" {
+		return false
+	}
+	guildID, err := bh.guildID()
+	if err != nil {
+		log.Printf("error checking the admin role of %s: %v", req.userID, err)
+		return false
+	}
+	member, err := bh.Session.GuildMember(guildID, req.userID)
+	if err != nil {
+		log.Printf("error checking the admin role of %s: %v", req.userID, err)
+		return false
+	}
+	for _, roleID := range member.Roles {
+		if roleID == role {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshCommand checks for updates right away. Only the bot's own !update
+// cooldown is skipped: the AoC client still spaces its requests out.
+func (bh *BotHandler) refreshCommand(req request) {
+	changes, err := bh.CheckForUpdates(bh.ctx)
+	if err != nil {
+		log.Printf("error checking for updates: %v", err)
+	}
+	bh.answerUpdate(req, changes, err)
+}
+
+func (bh *BotHandler) setPaused(req request, paused bool) {
+	if err := bh.Admin.Update(func(s *admin.Settings) { s.Paused = paused }); err != nil {
+		log.Printf("error pausing notifications: %v", err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	if paused {
+		bh.reply(req, messages.AdminPaused, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.AdminResumed, messages.Data{})
+}
+
+// channelCommand sends announcements to another channel, given as a channel
+// mention or ID. The bot has to be able to see it.
+func (bh *BotHandler) channelCommand(req request, arg string) {
+	channelID := strings.TrimSuffix(strings.TrimPrefix(arg, "<#"), ">")
+	if _, err := bh.Session.Channel(channelID); err != nil {
+		log.Printf("error getting channel %s: %v", channelID, err)
+		bh.reply(req, messages.AdminUsage, messages.Data{})
+		return
+	}
+	if err := bh.Admin.Update(func(s *admin.Settings) { s.ChannelID = channelID }); err != nil {
+		log.Printf("error changing the announcement channel: %v", err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.AdminChannelSet, messages.Data{Channel: channelID})
+}
+
+// aliasCommand shows a member under another name everywhere, or under their
+// AoC name again when alias is empty.
+func (bh *BotHandler) aliasCommand(req request, query, alias string) {
+	member, ok := findMember(bh.Tracker.Snapshot().Current, query)
+	if !ok {
+		bh.reply(req, messages.LinkNotFound, messages.Data{Member: query})
+		return
+	}
+	err := bh.Admin.Update(func(s *admin.Settings) {
+		if alias == "" {
+			delete(s.Aliases, member.ID)
+			return
+		}
+		if s.Aliases == nil {
+			s.Aliases = make(map[int]string)
+		}
+		s.Aliases[member.ID] = alias
+	})
+	if err != nil {
+		log.Printf("error setting the alias of %s: %v", member.Name, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.Tracker.SetAliases(bh.Admin.Get().Aliases)
+
+	if alias == "" {
+		bh.reply(req, messages.AdminAliasCleared, messages.Data{Member: member.Name})
+		return
+	}
+	bh.reply(req, messages.AdminAliasSet, messages.Data{Member: member.Name, Alias: alias})
+}
+
+// adminLinkCommand links a user to a member on their behalf. A member linked
+// to someone else is taken away from them, which is how an admin fixes a
+// member claimed by the wrong user.
+func (bh *BotHandler) adminLinkCommand(req request, userID, query string) {
+	member, ok := findMember(bh.Tracker.Snapshot().Current, query)
+	if !ok {
+		bh.reply(req, messages.LinkNotFound, messages.Data{Member: query})
+		return
+	}
+	if previous, ok := bh.Profiles.UserForMember(member.ID); ok && previous != userID {
+		if err := bh.unlinkUser(previous); err != nil {
+			log.Printf("error unlinking user %s: %v", previous, err)
+			bh.reply(req, messages.ProfileError, messages.Data{})
+			return
+		}
+	}
+	err := bh.Profiles.Update(userID, func(p *profiles.Profile) {
+		p.MemberID = member.ID
+	})
+	if err != nil {
+		log.Printf("error linking user %s: %v", userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.AdminLinked, messages.Data{Mention: "<@" + userID + ">", Member: member.Name})
+
+	if err := bh.SyncRoles(); err != nil {
+		log.Printf("error syncing roles: %v", err)
+	}
+}
+
+// adminUnlinkCommand removes a user's link on their behalf.
+func (bh *BotHandler) adminUnlinkCommand(req request, userID string) {
+	if err := bh.unlinkUser(userID); err != nil {
+		log.Printf("error unlinking user %s: %v", userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.AdminUnlinked, messages.Data{Mention: "<@" + userID + ">"})
+}
+
+// mentionedUser returns the user ID in a mention such as <@123> or <@!123>,
+// or arg itself if it is a plain ID.
+func mentionedUser(arg string) string {
+	arg = strings.TrimPrefix(arg, "<@")
+	arg = strings.TrimPrefix(arg, "!")
+	return strings.TrimSuffix(arg, ">")
+}
+
+func (bh *BotHandler) statusCommand(req request) {
+	msgs := bh.Messages()
+	status := bh.Tracker.Status()
+
+	lines := []string{
+		msgs.Render(messages.AdminStatusFetch, messages.Data{Time: status.LastSuccess, Count: status.MemberCount}),
+		msgs.Render(messages.AdminStatusChannel, messages.Data{Channel: bh.AnnouncementChannel()}),
+	}
+	if bh.paused() {
+		lines = append(lines, msgs.Render(messages.AdminStatusPaused, messages.Data{}))
+	}
+	recent := bh.errors.Recent()
+	if len(recent) == 0 {
+		lines = append(lines, msgs.Render(messages.AdminStatusNoErrors, messages.Data{}))
+	}
+	for _, entry := range recent {
+		lines = append(lines, msgs.Render(messages.AdminStatusError, messages.Data{Time: entry.Time, Description: entry.Err}))
+	}
+	bh.reply(req, messages.AdminStatus, messages.Data{Lines: lines})
+}
+
+// AnnouncementChannel returns the channel announcements go to: the one an
+// admin picked, or CHANNEL_ID.
+func (bh *BotHandler) AnnouncementChannel() string {
+	if bh.Admin != nil {
+		if channelID := bh.Admin.Get().ChannelID; channelID != "" {
+			return channelID
+		}
+	}
+	return bh.config().ChannelID
+}
+
+// paused reports whether an admin has paused notifications.
+func (bh *BotHandler) paused() bool {
+	return bh.Admin != nil && bh.Admin.Get().Paused
+}
+
+// recordError logs an error and keeps it for !admin status.
+func (bh *BotHandler) recordError(what string, err error) {
+	log.Printf("error %s: %v", what, err)
+	bh.errors.Add(time.Now(), fmt.Errorf("%s: %w", what, err))
+}
@@ -0,0 +1,13 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentionedUser(t *testing.T) {
+	assert.Equal(t, "123", mentionedUser("<@123>"), "A mention should give the user ID")
+	assert.Equal(t, "123", mentionedUser("<@!123>"), "A nickname mention should give the user ID")
+	assert.Equal(t, "123", mentionedUser("123"), "A plain ID should be kept")
+}
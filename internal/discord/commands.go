@@ -0,0 +1,359 @@
+package discord
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/cooldown"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+// command is a chat command such as "!leaderboard". description names the
+// message that describes it in !help, and limit how often it can be used.
+// shared is set for commands that only show something and answer everyone
+// the same way, so that one answer can serve everyone who asks the same
+// thing at once. !times and !vs are not shared, as they depend on the
+// user's timezone and link.
+type command struct {
+	name        string
+	description string
+	run         func(req request)
+	limit       cooldown.Rule
+	shared      bool
+}
+
+// updateInterval is how often !update may fetch the leaderboard, whoever
+// asks.
+const updateInterval = 15 * time.Minute
+
+var (
+	// readLimit applies to the commands that post a leaderboard, grid or
+	// comparison, which take up a lot of the channel.
+	readLimit = cooldown.Rule{
+		PerUser:    cooldown.Limit{Count: 3, Window: time.Minute},
+		PerChannel: cooldown.Limit{Count: 6, Window: time.Minute},
+	}
+	// settingsLimit applies to the commands that change a user's settings.
+	settingsLimit = cooldown.Rule{
+		PerUser: cooldown.Limit{Count: 5, Window: time.Minute},
+	}
+	// helpLimit keeps !help from being posted over and over.
+	helpLimit = cooldown.Rule{
+		PerChannel: cooldown.Limit{Count: 1, Window: 30 * time.Second},
+	}
+)
+
+// request is one use of a command: who sent it, where to reply and the
+// arguments that followed the command name. message refers to the message
+// that used the command, which replies are attached to.
+type request struct {
+	channelID string
+	userID    string
+	args      []string
+	message   *discordgo.MessageReference
+}
+
+// commands lists the commands in the order they are shown by !help.
+func (bh *BotHandler) commands() []command {
+	return []command{
+		{"!leaderboard", messages.CommandLeaderboard, bh.leaderboardCommand, readLimit, true},
+		{"!update", messages.CommandUpdate, bh.updateCommand, readLimit, true},
+		{"!stars", messages.CommandStars, bh.starsCommand, readLimit, true},
+		{"!alltime", messages.CommandAllTime, bh.allTimeCommand, readLimit, true},
+		{"!times", messages.CommandTimes, bh.timesCommand, readLimit, false},
+		{"!vs", messages.CommandVs, bh.vsCommand, readLimit, false},
+		{"!link", messages.CommandLink, bh.linkCommand, settingsLimit, false},
+		{"!unlink", messages.CommandUnlink, bh.unlinkCommand, settingsLimit, false},
+		{"!timezone", messages.CommandTimezone, bh.timezoneCommand, settingsLimit, false},
+		{"!remind", messages.CommandRemind, bh.remindCommand, settingsLimit, false},
+		{"!admin", messages.CommandAdmin, bh.adminCommand, cooldown.Rule{}, false},
+		{"!help", messages.CommandHelp, bh.helpCommand, helpLimit, true},
+	}
+}
+
+// dispatch runs a command unless it is over its limit, in which case the
+// user is told when they can use it again. A request identical to one still
+// being answered in the same channel is not run again: the user is pointed
+// at the answer to the first one. Only shared commands are merged across
+// users; the others are merged only with the same user's own requests.
+func (bh *BotHandler) dispatch(cmd command, req request) {
+	key := req.channelID + " " + cmd.name + " " + strings.ToLower(strings.Join(req.args, " "))
+	if !cmd.shared {
+		key = req.userID + " " + key
+	}
+	if !bh.inFlight.Start(key) {
+		log.Printf("%s is already being answered in %s, not running it again", cmd.name, req.channelID)
+		bh.reply(req, messages.CommandInProgress, messages.Data{Command: cmd.name})
+		return
+	}
+	defer bh.inFlight.Done(key)
+
+	decision := bh.cooldowns.Allow(cmd.name, req.userID, req.channelID, cmd.limit, time.Now())
+	if !decision.Allowed {
+		if decision.Notify {
+			bh.reply(req, messages.CommandCooldown, messages.Data{Command: cmd.name, Elapsed: retryAfter(decision.Wait)})
+		}
+		return
+	}
+	cmd.run(req)
+}
+
+// retryAfter rounds a wait up for display, to the minute once it is longer
+// than one, so that users are never told to come back too early.
+func retryAfter(wait time.Duration) time.Duration {
+	unit := time.Second
+	if wait > time.Minute {
+		unit = time.Minute
+	}
+	if rounded := wait.Truncate(unit); rounded < wait {
+		return rounded + unit
+	}
+	return wait
+}
+
+// reply renders the named message and sends it as a reply to the request.
+func (bh *BotHandler) reply(req request, name string, data messages.Data) {
+	bh.SendReply(req.channelID, req.message, bh.Messages().Render(name, data))
+}
+
+// answerUpdate answers a request for an update with what the update cycle
+// found: the changes, or the summary of a new baseline. They are posted as a
+// reply, unless they were just announced in the channel the request came
+// from. A cycle that failed before it got a leaderboard is reported as such.
+func (bh *BotHandler) answerUpdate(req request, changes leaderboard.Changes, err error) {
+	if err != nil && changes.Leaderboard == nil {
+		bh.reply(req, messages.UpdateFailed, messages.Data{})
+		return
+	}
+	if changes.Baseline {
+		if bh.paused() || req.channelID != bh.AnnouncementChannel() {
+			bh.SendReply(req.channelID, req.message, bh.format().FormatBaseline(changes.Leaderboard))
+		}
+		return
+	}
+	if !changes.HasUpdates() {
+		bh.reply(req, messages.NoUpdates, messages.Data{})
+		return
+	}
+	announced := !bh.paused() && bh.config().NotifyMode == config.NotifyImmediate
+	if announced && req.channelID == bh.AnnouncementChannel() {
+		return
+	}
+	bh.SendReplyEmbed(req.channelID, req.message, bh.format().FormatChanges(changes))
+}
+
+func (bh *BotHandler) updateCommand(req request) {
+	log.Println("Update command received")
+	since := time.Since(bh.Tracker.Snapshot().LastUpdate)
+	if since > updateInterval {
+		changes, err := bh.CheckForUpdates(bh.ctx)
+		if err != nil {
+			log.Printf("error checking for updates: %v", err)
+		}
+		bh.answerUpdate(req, changes, err)
+	} else {
+		bh.reply(req, messages.UpdateCooldown, messages.Data{Elapsed: retryAfter(updateInterval - since)})
+	}
+}
+
+func (bh *BotHandler) leaderboardCommand(req request) {
+	log.Println("Leaderboard command received")
+	year := 0
+	if len(req.args) > 0 {
+		year, _ = strconv.Atoi(req.args[0])
+	}
+	leaderboard, ok := bh.yearLeaderboard(req, year)
+	if !ok {
+		return
+	}
+	formattedLeaderboard := bh.format().FormatLeaderboard(leaderboard)
+	bh.SendReplyEmbed(req.channelID, req.message, formattedLeaderboard)
+}
+
+// starsCommand shows the star grid. Its arguments can come in any order: a
+// year, a day or range of days, "compact" or "wide", and "stars" or "score"
+// to sort by.
+func (bh *BotHandler) starsCommand(req request) {
+	log.Println("Stars command received")
+	var opts leaderboard.StarsOptions
+	year := 0
+	for _, arg := range req.args {
+		switch arg = strings.ToLower(arg); {
+		case arg == "compact":
+			opts.Compact = true
+		case arg == "wide":
+			opts.Compact = false
+		case arg == "stars":
+			opts.ByStars = true
+		case arg == "score":
+			opts.ByStars = false
+		case isYear(arg):
+			year, _ = strconv.Atoi(arg)
+		default:
+			from, to, ok := leaderboard.ParseDayRange(arg)
+			if !ok {
+				bh.reply(req, messages.StarsUsage, messages.Data{})
+				return
+			}
+			opts.From, opts.To = from, to
+		}
+	}
+
+	board, ok := bh.yearLeaderboard(req, year)
+	if !ok {
+		return
+	}
+	formatter := bh.format()
+	if from, to := formatter.StarsDays(board, opts); from > to {
+		_, unlocked := formatter.StarsDays(board, leaderboard.StarsOptions{})
+		bh.reply(req, messages.StarsNoDays, messages.Data{From: opts.From, To: opts.To, Count: unlocked})
+		return
+	}
+	bh.SendReplyEmbed(req.channelID, req.message, formatter.FormatStarsWith(board, opts))
+}
+
+// isYear reports whether a command argument is an event year rather than a
+// day.
+func isYear(arg string) bool {
+	year, err := strconv.Atoi(arg)
+	return err == nil && year >= aoc.FirstEvent
+}
+
+// yearLeaderboard returns the leaderboard of the year a command asked for:
+// the tracked one for 0 or the tracked year, and otherwise the archived one.
+// If that year is not archived it replies so and returns false.
+func (bh *BotHandler) yearLeaderboard(req request, year int) (*aoc.Leaderboard, bool) {
+	current := bh.Tracker.Snapshot().Current
+	if year == 0 || year == bh.config().AOCYear {
+		return current, true
+	}
+	if bh.Archive != nil {
+		if leaderboard, ok := bh.Archive.Get(year); ok {
+			return leaderboard, true
+		}
+	}
+	bh.reply(req, messages.YearUnknown, messages.Data{Year: year})
+	return nil, false
+}
+
+// allTimeCommand shows every member's stars and points added up over all
+// archived years, ranked by stars or by points.
+func (bh *BotHandler) allTimeCommand(req request) {
+	byPoints := false
+	if len(req.args) > 0 {
+		switch strings.ToLower(req.args[0]) {
+		case "stars":
+		case "points":
+			byPoints = true
+		default:
+			bh.reply(req, messages.AllTimeUsage, messages.Data{})
+			return
+		}
+	}
+
+	years := make(map[int]*aoc.Leaderboard)
+	if bh.Archive != nil {
+		years = bh.Archive.All()
+	}
+	// The tracked year is kept up to date by the tracker, not the archive.
+	if current := bh.Tracker.Snapshot().Current; current != nil {
+		years[bh.config().AOCYear] = current
+	}
+	leaderboards := make([]*aoc.Leaderboard, 0, len(years))
+	for _, board := range years {
+		leaderboards = append(leaderboards, board)
+	}
+
+	standings := leaderboard.AllTime(leaderboards, byPoints)
+	bh.SendReplyEmbed(req.channelID, req.message, bh.format().FormatAllTime(standings, byPoints))
+}
+
+// timesCommand shows the solve times for a day, by default the latest one
+// anyone has solved. Times are shown in the user's own timezone if they have
+// set one.
+func (bh *BotHandler) timesCommand(req request) {
+	current := bh.Tracker.Snapshot().Current
+	calendar := aoc.CalendarFor(bh.config().AOCYear)
+	day := leaderboard.LatestDay(current)
+	if len(req.args) > 0 {
+		parsed, err := strconv.Atoi(req.args[0])
+		if err != nil || !calendar.Valid(parsed) {
+			bh.reply(req, messages.TimesUsage, messages.Data{})
+			return
+		}
+		day = parsed
+	}
+	if day == 0 {
+		day = 1
+	}
+
+	var loc *time.Location
+	if bh.Profiles != nil {
+		if profile, ok := bh.Profiles.Get(req.userID); ok {
+			loc = profile.Location()
+		}
+	}
+	bh.SendReply(req.channelID, req.message, bh.format().FormatSolveTimes(current, day, loc))
+}
+
+// vsCommand compares two members of the tracked leaderboard day by day.
+// Names can contain spaces, so every split of the arguments into two members
+// is tried until both sides resolve.
+func (bh *BotHandler) vsCommand(req request) {
+	if len(req.args) < 2 {
+		bh.reply(req, messages.VsUsage, messages.Data{})
+		return
+	}
+
+	current := bh.Tracker.Snapshot().Current
+	var missing string
+	for split := 1; split < len(req.args); split++ {
+		first := strings.Join(req.args[:split], " ")
+		second := strings.Join(req.args[split:], " ")
+		member, ok := bh.vsMember(req, current, first)
+		if !ok {
+			missing = first
+			continue
+		}
+		rival, ok := bh.vsMember(req, current, second)
+		if !ok {
+			missing = second
+			continue
+		}
+		versus := leaderboard.Compare(current, member, rival)
+		bh.SendReplyEmbed(req.channelID, req.message, bh.format().FormatVersus(versus))
+		return
+	}
+	bh.reply(req, messages.LinkNotFound, messages.Data{Member: missing})
+}
+
+// vsMember resolves one side of !vs: "me" is the requester's linked member,
+// anything else a member name or AoC id.
+func (bh *BotHandler) vsMember(req request, current *aoc.Leaderboard, query string) (aoc.Member, bool) {
+	if strings.EqualFold(query, "me") && bh.Profiles != nil {
+		if profile, ok := bh.Profiles.Get(req.userID); ok && profile.Linked() {
+			return findMember(current, strconv.Itoa(profile.MemberID))
+		}
+	}
+	return findMember(current, query)
+}
+
+func (bh *BotHandler) helpCommand(req request) {
+	msgs := bh.Messages()
+	sb := strings.Builder{}
+	sb.WriteString("```")
+	sb.WriteString(msgs.Render(messages.HelpHeading, messages.Data{}) + "\n")
+	for _, cmd := range bh.commands() {
+		description := msgs.Render(cmd.description, messages.Data{})
+		line := msgs.Render(messages.HelpLine, messages.Data{Command: cmd.name, Description: description})
+		sb.WriteString("\n" + line + "\n")
+	}
+	sb.WriteString("```")
+	bh.SendReply(req.channelID, req.message, sb.String())
+}
@@ -0,0 +1,53 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerUpdate(t *testing.T) {
+	current := &aoc.Leaderboard{Event: "2024", OwnerID: 12345, Members: map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 4},
+		"2": {ID: 2, Name: "User2", Stars: 2},
+	}}
+	baseline := leaderboard.FormatBaseline(current)
+	failed := messages.Default().Render(messages.UpdateFailed, messages.Data{})
+
+	tests := []struct {
+		name      string
+		channelID string
+		changes   leaderboard.Changes
+		err       error
+		want      []string
+	}{
+		{"fetch failed", "commands", leaderboard.Changes{}, &leaderboard.CycleError{Stage: leaderboard.StageFetch, Err: errors.New("timeout")}, []string{failed}},
+		{"cancelled", "commands", leaderboard.Changes{}, context.Canceled, []string{failed}},
+		{"baseline", "commands", leaderboard.Changes{Leaderboard: current, Baseline: true}, nil, []string{baseline}},
+		{"baseline announced in the channel", "announcements", leaderboard.Changes{Leaderboard: current, Baseline: true}, nil, nil},
+		{"baseline not saved", "commands", leaderboard.Changes{Leaderboard: current, Baseline: true}, &leaderboard.CycleError{Stage: leaderboard.StagePersist, Err: errors.New("disk full")}, []string{baseline}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := discordgo.New("Bot test-token")
+			assert.NoError(t, err, "Creating the session should not return an error")
+			transport := &recordingTransport{}
+			session.Client = &http.Client{Transport: transport}
+			cfg := &config.Config{ChannelID: "announcements", Locale: "en", Timezone: "UTC", NotifyMode: config.NotifyImmediate}
+			bh := NewBotHandler(context.Background(), session, nil, cfg)
+			req := request{channelID: tt.channelID, userID: "user", message: &discordgo.MessageReference{MessageID: "command", ChannelID: tt.channelID}}
+
+			bh.answerUpdate(req, tt.changes, tt.err)
+
+			assert.Equal(t, tt.want, transport.messages, "The reply should say what the update found")
+		})
+	}
+}
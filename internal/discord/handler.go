@@ -1,122 +1,360 @@
 package discord
 
 import (
+	"github.com/PaytonWebber/aoc-discord-bot/internal/admin"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/archive"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/cooldown"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/profiles"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/scheduler"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/threads"
 	"github.com/bwmarrin/discordgo"
 
+	"context"
 	"log"
 	"strings"
+	"sync"
 	"time"
 )
 
+// PuzzleTitles looks up the titles of puzzles.
+type PuzzleTitles interface {
+	// PuzzleTitle fetches a title, unless it is cached.
+	PuzzleTitle(ctx context.Context, year, day int) (string, error)
+	// CachedPuzzleTitle returns a title if it is cached.
+	CachedPuzzleTitle(year, day int) (string, bool)
+}
+
 type BotHandler struct {
-	Session *discordgo.Session
-	Tracker *leaderboard.Tracker
-	cfg     *config.Config
+	Session  *discordgo.Session
+	Tracker  *leaderboard.Tracker
+	Profiles *profiles.Store
+	Threads  *threads.Store
+	Puzzles  PuzzleTitles
+	Archive  *archive.Archive
+	// Admin holds the settings changed with !admin.
+	Admin *admin.Store
+	// ReminderSchedule delivers the timed reminders. It is woken when a
+	// user changes theirs.
+	ReminderSchedule *scheduler.Timetable
+	cfg              *config.Config
+	formatter        *leaderboard.Formatter
+	mu               sync.RWMutex
+	digest           leaderboard.Digest
+	errors           *admin.ErrorLog
+	cooldowns        *cooldown.Limiter
+	inFlight         *cooldown.InFlight
+	// ctx is cancelled when the bot shuts down, which aborts the update
+	// cycles that commands started.
+	ctx context.Context
+	// syncing serializes syncMembers, which the poller, commands and day
+	// changes all run.
+	syncing sync.Mutex
+	// streakYears are the events whose streaks have been synced since the
+	// bot started. It is guarded by syncing.
+	streakYears map[int]bool
 }
 
-func NewBotHandler(session *discordgo.Session, tracker *leaderboard.Tracker, cfg *config.Config) *BotHandler {
-	return &BotHandler{
-		Session: session,
-		Tracker: tracker,
-		cfg:     cfg,
+// NewBotHandler creates the bot's handler. Work started by commands runs
+// under ctx, which should be cancelled when the bot shuts down.
+func NewBotHandler(ctx context.Context, session *discordgo.Session, tracker *leaderboard.Tracker, cfg *config.Config) *BotHandler {
+	bh := &BotHandler{
+		Session:   session,
+		Tracker:   tracker,
+		cfg:       cfg,
+		errors:    admin.NewErrorLog(admin.DefaultErrorLogSize),
+		cooldowns: cooldown.NewLimiter(),
+		inFlight:  cooldown.NewInFlight(),
+		ctx:       ctx,
 	}
+	bh.formatter = bh.newFormatter(cfg)
+	return bh
 }
 
-func (bh *BotHandler) CheckForUpdates() (bool, error) {
-	log.Println("Checking for updates...")
+// newFormatter builds the formatter for the configured locale and message
+// templates.
+// They have been validated with the configuration, so an error here should
+// not happen; the built-in messages are used if it does.
+func (bh *BotHandler) newFormatter(cfg *config.Config) *leaderboard.Formatter {
+	msgs, err := messages.New(cfg.Locale, cfg.Messages)
+	if err != nil {
+		log.Printf("error loading message templates, using the defaults: %v", err)
+		msgs = messages.Default()
+	}
+	formatter := leaderboard.NewFormatter(msgs, cfg.Location())
+	formatter.Titles = bh.cachedTitle
+	return formatter
+}
 
-	bh.Tracker.LastUpdate = time.Now()
-	bh.Tracker.UpdateLeaderboard()
-	leaderboard.StoreLeaderboard(bh.Tracker.CurrentLeaderboard)
+// cachedTitle returns the title of a puzzle if it has been fetched, or "".
+func (bh *BotHandler) cachedTitle(year, day int) string {
+	if bh.Puzzles == nil {
+		return ""
+	}
+	title, _ := bh.Puzzles.CachedPuzzleTitle(year, day)
+	return title
+}
 
-	hadUpdates := false
-	newStars, err := bh.Tracker.CheckForNewStars()
-	if err != nil {
-		return hadUpdates, err
+// fetchTitles makes sure the titles of the given days are cached, so that
+// messages about them can show the title. A title that cannot be fetched is
+// left out.
+func (bh *BotHandler) fetchTitles(ctx context.Context, year int, days []int) {
+	if bh.Puzzles == nil {
+		return
 	}
+	for _, day := range days {
+		if _, err := bh.Puzzles.PuzzleTitle(ctx, year, day); err != nil {
+			log.Printf("error fetching the title of day %d: %v", day, err)
+		}
+	}
+}
+
+// ApplyConfig swaps in a new configuration, e.g. after a reload.
+func (bh *BotHandler) ApplyConfig(cfg *config.Config) {
+	formatter := bh.newFormatter(cfg)
+
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+	bh.cfg = cfg
+	bh.formatter = formatter
+}
+
+func (bh *BotHandler) config() *config.Config {
+	bh.mu.RLock()
+	defer bh.mu.RUnlock()
+	return bh.cfg
+}
+
+func (bh *BotHandler) format() *leaderboard.Formatter {
+	bh.mu.RLock()
+	defer bh.mu.RUnlock()
+	return bh.formatter
+}
 
-	newMembers, err := bh.Tracker.CheckForNewMembers()
+// Messages returns the message templates in use.
+func (bh *BotHandler) Messages() *messages.Set {
+	return bh.format().Messages
+}
+
+// CheckForUpdates runs an update cycle, announces anything new and brings
+// the day threads, members' reward roles and streaks up to date. Users who
+// asked for it are told when someone passed them. It returns what the cycle
+// found. Cancelling ctx aborts the leaderboard fetch.
+func (bh *BotHandler) CheckForUpdates(ctx context.Context) (leaderboard.Changes, error) {
+	log.Println("Checking for updates...")
+
+	changes, err := bh.Tracker.Refresh(ctx, func(changes leaderboard.Changes) error {
+		return bh.announceChanges(ctx, changes)
+	})
 	if err != nil {
-		return hadUpdates, err
+		bh.errors.Add(time.Now(), err)
 	}
+	bh.remindPassed(changes)
+	bh.syncMembers(ctx, time.Now())
+	return changes, err
+}
 
-	if len(newStars) > 0 {
-		log.Printf("new stars: %v", newStars)
-		for _, member := range newStars {
-			bh.SendChannelMessage(bh.cfg.ChannelID, member+" got a star! 🌟")
-		}
+// NextDayChange returns the first puzzle unlock of the tracked year after the
+// given time, or the end of the last puzzle day.
+func (bh *BotHandler) NextDayChange(after time.Time) (time.Time, bool) {
+	return aoc.CalendarFor(bh.config().AOCYear).NextDayChange(after)
+}
+
+// OnDayChange runs when a new puzzle unlocks or the last one ends. The day
+// that is over gets its results posted, the new one gets its thread, and
+// roles earned for the previous day, like "solved today", are handed back
+// out for the new one.
+func (bh *BotHandler) OnDayChange(ctx context.Context, at time.Time) {
+	log.Printf("Puzzle day changed at %s", at)
+	bh.syncMembers(ctx, at)
+}
+
+// syncMembers brings the day threads, reward roles and streak announcements
+// up to date, logging anything that could not be done. Only one sync runs at
+// a time, so a thread is never opened or closed twice.
+func (bh *BotHandler) syncMembers(ctx context.Context, now time.Time) {
+	bh.syncing.Lock()
+	defer bh.syncing.Unlock()
+
+	if err := bh.SyncThreads(ctx, now); err != nil {
+		bh.recordError("syncing threads", err)
+	}
+	if err := bh.SyncRoles(); err != nil {
+		bh.recordError("syncing roles", err)
+	}
+	if err := bh.SyncStreaks(now); err != nil {
+		bh.recordError("syncing streaks", err)
 	}
+}
 
-	if len(newMembers) > 0 {
-		log.Printf("new members: %v", newMembers)
-		bh.SendChannelMessage(bh.cfg.ChannelID, "CHALLENGER APPROACHING!")
-		for _, member := range newMembers {
-			bh.SendChannelMessage(bh.cfg.ChannelID, member+" has joined the leaderboard!")
-		}
+// announceChanges posts the changes found by an update cycle as a single
+// embed, or queues them for the next digest in digest mode. A new baseline is
+// always announced right away as a summary. While an admin has paused
+// notifications nothing is announced or queued: the changes are dropped for
+// good, in digest mode too, and only the leaderboard state moves on.
+func (bh *BotHandler) announceChanges(ctx context.Context, changes leaderboard.Changes) error {
+	cfg := bh.config()
+	channelID := bh.AnnouncementChannel()
+
+	if bh.paused() {
+		log.Printf("Notifications are paused, not announcing changes")
+		return nil
+	}
+	if changes.Baseline {
+		return bh.SendChannelMessage(channelID, bh.format().FormatBaseline(changes.Leaderboard))
+	}
+
+	if len(changes.NewStars) > 0 {
+		log.Printf("new stars: %v", changes.NewStars)
+	}
+	if len(changes.NewMembers) > 0 {
+		log.Printf("new members: %v", changes.NewMembers)
 	}
 
-	if len(newStars) > 0 || len(newMembers) > 0 {
-		hadUpdates = true
-		formattedLeaderboard := leaderboard.FormatLeaderboard(bh.Tracker.CurrentLeaderboard)
-		bh.SendChannelMessageEmbed(bh.cfg.ChannelID, formattedLeaderboard)
+	bh.fetchTitles(ctx, cfg.AOCYear, starDays(changes.Stars))
+
+	if cfg.NotifyMode == config.NotifyDigest {
+		bh.digest.Add(changes)
+		return nil
 	}
+	return bh.SendChannelMessageEmbed(channelID, bh.format().FormatChanges(changes))
+}
 
-	return hadUpdates, nil
+// FlushDigest posts the changes collected since the last digest, if any. If
+// sending fails they are kept for the next digest.
+func (bh *BotHandler) FlushDigest(ctx context.Context) {
+	if bh.paused() {
+		return
+	}
+	changes, ok := bh.digest.Flush()
+	if !ok {
+		return
+	}
+	if err := bh.SendChannelMessageEmbed(bh.AnnouncementChannel(), bh.format().FormatChanges(changes)); err != nil {
+		bh.digest.Add(changes)
+	}
 }
 
+// MessageReceived runs the command a message starts with, if it was sent
+// where commands are allowed.
 func (bh *BotHandler) MessageReceived(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.Author.ID == s.State.User.ID || m.ChannelID != bh.cfg.ChannelID {
+	if m.Author.ID == s.State.User.ID {
 		return
 	}
 
-	if strings.ToLower(m.Content) == "!update" {
-		log.Println("Update command received")
-		if time.Since(bh.Tracker.LastUpdate).Minutes() > (15 * time.Minute).Minutes() {
-			hadUpdates, err := bh.CheckForUpdates()
-			if err != nil {
-				log.Printf("error checking for updates: %v", err)
-			}
-			if !hadUpdates {
-				bh.SendChannelMessage(bh.cfg.ChannelID, "No updates")
+	fields := strings.Fields(m.Content)
+	if len(fields) == 0 {
+		return
+	}
+
+	name := strings.ToLower(fields[0])
+	for _, cmd := range bh.commands() {
+		if cmd.name == name {
+			if !bh.commandsAllowed(m.Message) {
+				return
 			}
-		} else {
-			bh.SendChannelMessage(bh.cfg.ChannelID, "You can only update once every 15 minutes")
+			metrics.CommandInvocations.WithLabelValues(cmd.name).Inc()
+			bh.dispatch(cmd, request{channelID: m.ChannelID, userID: m.Author.ID, args: fields[1:], message: m.Reference()})
+			return
+		}
+	}
+}
+
+// commandsAllowed reports whether commands are answered where a message was
+// sent: the announcement channel, the channels in COMMAND_CHANNELS, the
+// threads in any of them, and direct messages if COMMAND_CHANNELS has "dm".
+func (bh *BotHandler) commandsAllowed(m *discordgo.Message) bool {
+	allowed := map[string]bool{bh.AnnouncementChannel(): true}
+	for _, channelID := range bh.config().CommandChannels {
+		allowed[channelID] = true
+	}
+	if m.GuildID == "" {
+		return allowed[config.CommandChannelDM]
+	}
+	if allowed[m.ChannelID] {
+		return true
+	}
+
+	channel, err := bh.Session.State.Channel(m.ChannelID)
+	if err != nil {
+		channel, err = bh.Session.Channel(m.ChannelID)
+		if err != nil {
+			log.Printf("error getting channel %s: %v", m.ChannelID, err)
+			return false
 		}
-	} else if strings.ToLower(m.Content) == "!leaderboard" {
-		log.Println("Leaderboard command received")
-		formattedLeaderboard := leaderboard.FormatLeaderboard(bh.Tracker.CurrentLeaderboard)
-		bh.SendChannelMessageEmbed(bh.cfg.ChannelID, formattedLeaderboard)
-
-	} else if strings.ToLower(m.Content) == "!stars" {
-		log.Println("Stars command received")
-		embed := leaderboard.FormatStars(bh.Tracker.CurrentLeaderboard)
-		bh.SendChannelMessageEmbed(bh.cfg.ChannelID, embed)
-
-	} else if strings.ToLower(m.Content) == "!help" {
-		sb := strings.Builder{}
-		sb.WriteString("```")
-		sb.WriteString("Commands:\n\n")
-		sb.WriteString("!leaderboard - Shows the current leaderboard\n\n")
-		sb.WriteString("!update - Checks for updates and shows the updated leaderboard\n\n")
-		sb.WriteString("!stars - Shows the current stars\n\n")
-		sb.WriteString("!help - Shows this message\n")
-		sb.WriteString("```")
-		bh.SendChannelMessage(bh.cfg.ChannelID, sb.String())
-	}
-}
-
-func (bh *BotHandler) SendChannelMessage(channelID, message string) {
+	}
+	return channel.IsThread() && allowed[channel.ParentID]
+}
+
+func (bh *BotHandler) SendChannelMessage(channelID, message string) error {
+	metrics.DiscordSends.WithLabelValues("text").Inc()
 	_, err := bh.Session.ChannelMessageSend(channelID, message)
 	if err != nil {
+		metrics.DiscordSendFailures.WithLabelValues("text").Inc()
 		log.Printf("error sending message: %v", err)
 	}
+	return err
+}
+
+// SendReply sends a message to a channel as a reply to another message.
+func (bh *BotHandler) SendReply(channelID string, reference *discordgo.MessageReference, message string) error {
+	metrics.DiscordSends.WithLabelValues("reply").Inc()
+	_, err := bh.Session.ChannelMessageSendReply(channelID, message, reference)
+	if err != nil {
+		metrics.DiscordSendFailures.WithLabelValues("reply").Inc()
+		log.Printf("error sending reply: %v", err)
+	}
+	return err
+}
+
+// SendReplyEmbed sends an embed to a channel as a reply to another message.
+func (bh *BotHandler) SendReplyEmbed(channelID string, reference *discordgo.MessageReference, embed *discordgo.MessageEmbed) error {
+	metrics.DiscordSends.WithLabelValues("reply").Inc()
+	_, err := bh.Session.ChannelMessageSendEmbedReply(channelID, embed, reference)
+	if err != nil {
+		metrics.DiscordSendFailures.WithLabelValues("reply").Inc()
+		log.Printf("error sending reply: %v", err)
+	}
+	return err
+}
+
+// SendDirectMessage sends a message to a user's DMs.
+func (bh *BotHandler) SendDirectMessage(userID, message string) error {
+	metrics.DiscordSends.WithLabelValues("direct").Inc()
+	channel, err := bh.Session.UserChannelCreate(userID)
+	if err == nil {
+		_, err = bh.Session.ChannelMessageSend(channel.ID, message)
+	}
+	if err != nil {
+		metrics.DiscordSendFailures.WithLabelValues("direct").Inc()
+		log.Printf("error sending direct message: %v", err)
+	}
+	return err
 }
 
-func (bh *BotHandler) SendChannelMessageEmbed(channelID string, embed *discordgo.MessageEmbed) {
+func (bh *BotHandler) SendChannelMessageEmbed(channelID string, embed *discordgo.MessageEmbed) error {
+	metrics.DiscordSends.WithLabelValues("embed").Inc()
 	_, err := bh.Session.ChannelMessageSendEmbed(channelID, embed)
 	if err != nil {
+		metrics.DiscordSendFailures.WithLabelValues("embed").Inc()
 		log.Printf("error sending message: %v", err)
 	}
+	return err
+}
+
+// starDays returns the days the stars were earned on, each once.
+func starDays(stars []leaderboard.StarEvent) []int {
+	seen := make(map[int]bool)
+	var days []int
+	for _, star := range stars {
+		if !seen[star.Day] {
+			seen[star.Day] = true
+			days = append(days, star.Day)
+		}
+	}
+	return days
 }
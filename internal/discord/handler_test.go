@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandsAllowed(t *testing.T) {
+	session, err := discordgo.New("Bot test-token")
+	assert.NoError(t, err, "Creating the session should not return an error")
+	guild := &discordgo.Guild{
+		ID: "guild",
+		Channels: []*discordgo.Channel{
+			{ID: "announcements", GuildID: "guild", Type: discordgo.ChannelTypeGuildText},
+			{ID: "commands", GuildID: "guild", Type: discordgo.ChannelTypeGuildText},
+			{ID: "random", GuildID: "guild", Type: discordgo.ChannelTypeGuildText},
+		},
+		Threads: []*discordgo.Channel{
+			{ID: "announcements-thread", GuildID: "guild", ParentID: "announcements", Type: discordgo.ChannelTypeGuildPublicThread},
+			{ID: "commands-thread", GuildID: "guild", ParentID: "commands", Type: discordgo.ChannelTypeGuildPrivateThread},
+			{ID: "random-thread", GuildID: "guild", ParentID: "random", Type: discordgo.ChannelTypeGuildPublicThread},
+		},
+	}
+	assert.NoError(t, session.State.GuildAdd(guild), "Adding the guild should not return an error")
+
+	cfg := &config.Config{ChannelID: "announcements", Locale: "en", Timezone: "UTC"}
+	bh := NewBotHandler(context.Background(), session, nil, cfg)
+
+	tests := []struct {
+		name     string
+		channels []string
+		message  *discordgo.Message
+		want     bool
+	}{
+		{"announcement channel", nil, &discordgo.Message{GuildID: "guild", ChannelID: "announcements"}, true},
+		{"thread of the announcement channel", nil, &discordgo.Message{GuildID: "guild", ChannelID: "announcements-thread"}, true},
+		{"listed channel", []string{"commands"}, &discordgo.Message{GuildID: "guild", ChannelID: "commands"}, true},
+		{"thread of a listed channel", []string{"commands"}, &discordgo.Message{GuildID: "guild", ChannelID: "commands-thread"}, true},
+		{"channel not listed", []string{"commands"}, &discordgo.Message{GuildID: "guild", ChannelID: "random"}, false},
+		{"thread of a channel not listed", []string{"commands"}, &discordgo.Message{GuildID: "guild", ChannelID: "random-thread"}, false},
+		{"direct message", []string{"commands"}, &discordgo.Message{ChannelID: "dm-channel"}, false},
+		{"direct message allowed", []string{config.CommandChannelDM}, &discordgo.Message{ChannelID: "dm-channel"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *cfg
+			cfg.CommandChannels = tt.channels
+			bh.ApplyConfig(&cfg)
+
+			assert.Equal(t, tt.want, bh.commandsAllowed(tt.message), "Commands should be allowed only where configured")
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package discord
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/profiles"
+)
+
+// linkCommand links the user to a leaderboard member, given by name or AoC
+// user ID.
+func (bh *BotHandler) linkCommand(req request) {
+	if len(req.args) == 0 || bh.Profiles == nil {
+		bh.reply(req, messages.LinkUsage, messages.Data{})
+		return
+	}
+
+	query := strings.Join(req.args, " ")
+	member, ok := findMember(bh.Tracker.Snapshot().Current, query)
+	if !ok {
+		bh.reply(req, messages.LinkNotFound, messages.Data{Member: query})
+		return
+	}
+	if userID, ok := bh.Profiles.UserForMember(member.ID); ok && userID != req.userID {
+		bh.reply(req, messages.LinkTaken, messages.Data{Member: member.Name})
+		return
+	}
+
+	err := bh.Profiles.Update(req.userID, func(p *profiles.Profile) {
+		p.MemberID = member.ID
+	})
+	if err != nil {
+		log.Printf("error linking user %s: %v", req.userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.Linked, messages.Data{Member: member.Name})
+
+	if err := bh.SyncRoles(); err != nil {
+		log.Printf("error syncing roles: %v", err)
+	}
+}
+
+func (bh *BotHandler) unlinkCommand(req request) {
+	if bh.Profiles == nil {
+		return
+	}
+	if err := bh.unlinkUser(req.userID); err != nil {
+		log.Printf("error unlinking user %s: %v", req.userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.Unlinked, messages.Data{})
+}
+
+// unlinkUser removes a user's link to their member, along with the roles it
+// earned them.
+func (bh *BotHandler) unlinkUser(userID string) error {
+	if err := bh.removeRoles(userID); err != nil {
+		log.Printf("error removing roles from %s: %v", userID, err)
+	}
+	return bh.Profiles.Update(userID, func(p *profiles.Profile) {
+		p.MemberID = 0
+	})
+}
+
+// timezoneCommand sets the timezone the user sees absolute times in.
+func (bh *BotHandler) timezoneCommand(req request) {
+	if bh.Profiles == nil {
+		return
+	}
+	if len(req.args) == 0 {
+		zone := bh.config().Location().String()
+		if profile, ok := bh.Profiles.Get(req.userID); ok && profile.Timezone != "" {
+			zone = profile.Timezone
+		}
+		bh.reply(req, messages.TimezoneUsage, messages.Data{Zone: zone})
+		return
+	}
+
+	zone := req.args[0]
+	loc, err := time.LoadLocation(zone)
+	if err != nil || zone == "Local" {
+		bh.reply(req, messages.TimezoneInvalid, messages.Data{Zone: zone})
+		return
+	}
+
+	err = bh.Profiles.Update(req.userID, func(p *profiles.Profile) {
+		p.Timezone = loc.String()
+	})
+	if err != nil {
+		log.Printf("error setting timezone for user %s: %v", req.userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	bh.reply(req, messages.TimezoneSet, messages.Data{Zone: loc.String()})
+}
+
+// findMember looks a member up by AoC user ID or, ignoring case, by name.
+func findMember(leaderboard *aoc.Leaderboard, query string) (aoc.Member, bool) {
+	if leaderboard == nil {
+		return aoc.Member{}, false
+	}
+	if id, err := strconv.Atoi(query); err == nil {
+		for _, member := range leaderboard.Members {
+			if member.ID == id {
+				return member, true
+			}
+		}
+	}
+	for _, member := range leaderboard.Members {
+		if strings.EqualFold(member.Name, query) {
+			return member, true
+		}
+	}
+	return aoc.Member{}, false
+}
@@ -0,0 +1,262 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/profiles"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/reminders"
+)
+
+// remindCommand subscribes the user to direct message reminders, lists them
+// or cancels them:
+//
+//	!remind at 20:00       DM at 20:00 in their timezone if the current puzzle is not finished
+//	!remind before 15      DM 15 minutes before each unlock
+//	!remind passed         DM when someone passes their member
+//	!remind off [kind]     cancel every reminder, or one kind
+func (bh *BotHandler) remindCommand(req request) {
+	if bh.Profiles == nil {
+		return
+	}
+	if len(req.args) == 0 {
+		bh.listReminders(req)
+		return
+	}
+
+	profile, _ := bh.Profiles.Get(req.userID)
+	var update func(*profiles.Profile)
+	switch kind := strings.ToLower(req.args[0]); {
+	case kind == "at" && len(req.args) == 2:
+		clock, ok := reminders.ParseClock(req.args[1])
+		if !ok {
+			bh.reply(req, messages.RemindUsage, messages.Data{})
+			return
+		}
+		if !profile.Linked() {
+			bh.reply(req, messages.RemindNeedsLink, messages.Data{})
+			return
+		}
+		update = func(p *profiles.Profile) { p.RemindAt = clock }
+	case kind == "before" && len(req.args) == 2:
+		minutes, err := strconv.Atoi(req.args[1])
+		if err != nil || minutes <= 0 || time.Duration(minutes)*time.Minute > reminders.MaxBefore {
+			bh.reply(req, messages.RemindUsage, messages.Data{})
+			return
+		}
+		update = func(p *profiles.Profile) { p.RemindBefore = minutes }
+	case kind == "passed" && len(req.args) == 1:
+		if !profile.Linked() {
+			bh.reply(req, messages.RemindNeedsLink, messages.Data{})
+			return
+		}
+		update = func(p *profiles.Profile) { p.RemindPassed = true }
+	case kind == "off" && len(req.args) <= 2:
+		which := ""
+		if len(req.args) == 2 {
+			which = strings.ToLower(req.args[1])
+		}
+		switch which {
+		case "", "at", "before", "passed":
+		default:
+			bh.reply(req, messages.RemindUsage, messages.Data{})
+			return
+		}
+		update = func(p *profiles.Profile) {
+			if which == "" || which == "at" {
+				p.RemindAt = ""
+			}
+			if which == "" || which == "before" {
+				p.RemindBefore = 0
+			}
+			if which == "" || which == "passed" {
+				p.RemindPassed = false
+			}
+		}
+	default:
+		bh.reply(req, messages.RemindUsage, messages.Data{})
+		return
+	}
+
+	if err := bh.Profiles.Update(req.userID, update); err != nil {
+		log.Printf("error updating reminders for user %s: %v", req.userID, err)
+		bh.reply(req, messages.ProfileError, messages.Data{})
+		return
+	}
+	if bh.ReminderSchedule != nil {
+		bh.ReminderSchedule.Wake()
+	}
+	if strings.EqualFold(req.args[0], "off") {
+		bh.reply(req, messages.RemindOff, messages.Data{})
+		return
+	}
+	bh.listReminders(req)
+}
+
+// listReminders replies with the reminders the user has subscribed to.
+func (bh *BotHandler) listReminders(req request) {
+	profile, _ := bh.Profiles.Get(req.userID)
+	if !profile.HasReminders() {
+		bh.reply(req, messages.RemindNone, messages.Data{})
+		return
+	}
+
+	msgs := bh.Messages()
+	var lines []string
+	if profile.RemindAt != "" {
+		lines = append(lines, msgs.Render(messages.RemindAtSet, messages.Data{Clock: profile.RemindAt, Zone: bh.userLocation(profile).String()}))
+	}
+	if profile.RemindBefore != 0 {
+		lines = append(lines, msgs.Render(messages.RemindBeforeSet, messages.Data{Count: profile.RemindBefore}))
+	}
+	if profile.RemindPassed {
+		lines = append(lines, msgs.Render(messages.RemindPassedSet, messages.Data{Member: bh.memberName(profile.MemberID)}))
+	}
+	bh.reply(req, messages.RemindList, messages.Data{Lines: lines})
+}
+
+// userLocation returns the timezone a user has picked, or the configured one.
+func (bh *BotHandler) userLocation(profile profiles.Profile) *time.Location {
+	if loc := profile.Location(); loc != nil {
+		return loc
+	}
+	return bh.config().Location()
+}
+
+// memberName returns the name of a member of the tracked leaderboard.
+func (bh *BotHandler) memberName(memberID int) string {
+	if member, ok := findMember(bh.Tracker.Snapshot().Current, strconv.Itoa(memberID)); ok {
+		return member.Name
+	}
+	return strconv.Itoa(memberID)
+}
+
+// dueReminder is a timed reminder of one user.
+type dueReminder struct {
+	message string
+	day     int
+	at      time.Time
+}
+
+// timedReminders returns the next "at" and "before" reminders of a user
+// after the given time.
+func (bh *BotHandler) timedReminders(calendar aoc.Calendar, profile profiles.Profile, after time.Time) []dueReminder {
+	var due []dueReminder
+	if profile.RemindAt != "" && profile.Linked() {
+		if day, at, ok := reminders.NextAt(calendar, profile.RemindAt, bh.userLocation(profile), after); ok {
+			due = append(due, dueReminder{messages.RemindAtMessage, day, at})
+		}
+	}
+	if profile.RemindBefore != 0 {
+		before := time.Duration(profile.RemindBefore) * time.Minute
+		if day, at, ok := reminders.NextBefore(calendar, before, after); ok {
+			due = append(due, dueReminder{messages.RemindBeforeMessage, day, at})
+		}
+	}
+	return due
+}
+
+// NextReminder returns the first time after the given one that any user is
+// due a timed reminder.
+func (bh *BotHandler) NextReminder(after time.Time) (time.Time, bool) {
+	if bh.Profiles == nil {
+		return time.Time{}, false
+	}
+	calendar := aoc.CalendarFor(bh.config().AOCYear)
+	var next time.Time
+	for _, profile := range bh.Profiles.All() {
+		for _, due := range bh.timedReminders(calendar, profile, after) {
+			if next.IsZero() || due.at.Before(next) {
+				next = due.at
+			}
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// OnReminder sends the timed reminders due at the given time. An "at"
+// reminder is only sent if the latest leaderboard shows the user's member
+// without both stars of the day.
+func (bh *BotHandler) OnReminder(ctx context.Context, at time.Time) {
+	if bh.Profiles == nil {
+		return
+	}
+	calendar := aoc.CalendarFor(bh.config().AOCYear)
+	current := bh.Tracker.Snapshot().Current
+	msgs := bh.Messages()
+
+	var errs []error
+	for userID, profile := range bh.Profiles.All() {
+		for _, due := range bh.timedReminders(calendar, profile, at.Add(-time.Nanosecond)) {
+			if !due.at.Equal(at) {
+				continue
+			}
+			data := messages.Data{Day: due.day, Time: calendar.Unlock(due.day)}
+			if due.message == messages.RemindAtMessage {
+				stars := memberStars(current, profile.MemberID, due.day)
+				if stars == 2 {
+					continue
+				}
+				bh.fetchTitles(ctx, calendar.Year, []int{due.day})
+				data.Stars = stars
+				data.Title = bh.cachedTitle(calendar.Year, due.day)
+			}
+			if err := bh.SendDirectMessage(userID, msgs.Render(due.message, data)); err != nil {
+				errs = append(errs, fmt.Errorf("error reminding %s: %w", userID, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("error sending reminders: %v", err)
+	}
+}
+
+// memberStars returns how many stars of a day a member has.
+func memberStars(current *aoc.Leaderboard, memberID, day int) int {
+	if current == nil {
+		return 0
+	}
+	level := current.Members[strconv.Itoa(memberID)].CompletionDayLevels[strconv.Itoa(day)]
+	stars := 0
+	if level.Level1 != nil {
+		stars++
+	}
+	if level.Level2 != nil {
+		stars++
+	}
+	return stars
+}
+
+// remindPassed tells users who asked for it that someone passed their member
+// in an update cycle. Like announcements, these are dropped while
+// notifications are paused.
+func (bh *BotHandler) remindPassed(changes leaderboard.Changes) {
+	if bh.Profiles == nil || changes.Baseline || !changes.HasUpdates() || bh.paused() {
+		return
+	}
+	msgs := bh.Messages()
+	for userID, profile := range bh.Profiles.All() {
+		if !profile.RemindPassed || !profile.Linked() {
+			continue
+		}
+		passed := reminders.Overtakers(changes.Previous, changes.Leaderboard, profile.MemberID)
+		if len(passed) == 0 {
+			continue
+		}
+		message := msgs.Render(messages.RemindPassedMessage, messages.Data{
+			Member:  bh.memberName(profile.MemberID),
+			Members: passed,
+		})
+		if err := bh.SendDirectMessage(userID, message); err != nil {
+			log.Printf("error telling %s they were passed: %v", userID, err)
+		}
+	}
+}
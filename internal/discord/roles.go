@@ -0,0 +1,105 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/roles"
+)
+
+// SyncRoles gives every linked member the reward roles they have earned and
+// takes away the ones they no longer have, so roles changed while the bot was
+// down are fixed too. A rule for a past year is decided on the archived
+// leaderboard of that year. Roles that no rule manages, or whose year has no
+// leaderboard yet, are left alone.
+func (bh *BotHandler) SyncRoles() error {
+	cfg := bh.config()
+	if len(cfg.Roles) == 0 || bh.Profiles == nil {
+		return nil
+	}
+	leaderboards := make(map[int]*aoc.Leaderboard)
+	if bh.Archive != nil {
+		leaderboards = bh.Archive.All()
+	}
+	if current := bh.Tracker.Snapshot().Current; current != nil {
+		leaderboards[cfg.AOCYear] = current
+	}
+	rules := roles.Decidable(cfg.Roles, leaderboards, cfg.AOCYear)
+	if len(rules) == 0 {
+		return nil
+	}
+	guildID, err := bh.guildID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for userID, profile := range bh.Profiles.All() {
+		if !profile.Linked() {
+			continue
+		}
+		desired := roles.Desired(rules, leaderboards, cfg.AOCYear, profile.MemberID, now)
+		if err := bh.applyRoles(guildID, userID, rules, desired); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeRoles takes every managed role away from a user, e.g. when they
+// unlink their member.
+func (bh *BotHandler) removeRoles(userID string) error {
+	rules := bh.config().Roles
+	if len(rules) == 0 {
+		return nil
+	}
+	guildID, err := bh.guildID()
+	if err != nil {
+		return err
+	}
+	return bh.applyRoles(guildID, userID, rules, nil)
+}
+
+// applyRoles adds and removes managed roles so the user has exactly the
+// desired ones.
+func (bh *BotHandler) applyRoles(guildID, userID string, rules []roles.Rule, desired map[string]bool) error {
+	member, err := bh.Session.GuildMember(guildID, userID)
+	if err != nil {
+		return fmt.Errorf("error getting guild member %s: %w", userID, err)
+	}
+
+	add, remove := roles.Plan(rules, desired, member.Roles)
+	var errs []error
+	for _, roleID := range add {
+		if err := bh.Session.GuildMemberRoleAdd(guildID, userID, roleID); err != nil {
+			errs = append(errs, fmt.Errorf("error adding role %s to %s: %w", roleID, userID, err))
+			continue
+		}
+		log.Printf("Gave role %s to %s", roleID, userID)
+	}
+	for _, roleID := range remove {
+		if err := bh.Session.GuildMemberRoleRemove(guildID, userID, roleID); err != nil {
+			errs = append(errs, fmt.Errorf("error removing role %s from %s: %w", roleID, userID, err))
+			continue
+		}
+		log.Printf("Removed role %s from %s", roleID, userID)
+	}
+	return errors.Join(errs...)
+}
+
+// guildID returns the server the bot's channel belongs to.
+func (bh *BotHandler) guildID() (string, error) {
+	channelID := bh.AnnouncementChannel()
+	channel, err := bh.Session.State.Channel(channelID)
+	if err != nil {
+		channel, err = bh.Session.Channel(channelID)
+		if err != nil {
+			return "", fmt.Errorf("error getting channel %s: %w", channelID, err)
+		}
+	}
+	return channel.GuildID, nil
+}
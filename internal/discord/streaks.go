@@ -0,0 +1,177 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/streaks"
+)
+
+// maxMessageLength is the longest message Discord accepts.
+const maxMessageLength = 2000
+
+// SyncStreaks announces members who reached a streak milestone and, with
+// STREAK_NUDGE set, nudges linked members whose streak is about to break.
+// While notifications are paused nothing is sent, so milestones reached in
+// the meantime are announced once they are resumed.
+// Each milestone of a streak is announced once and each day nudged about
+// once, which the star history remembers across restarts. The milestones of
+// a sync are announced together, and the first sync of an event after the
+// bot starts only records them, so that a first deploy does not announce
+// every streak already on the leaderboard. It must only be run by
+// syncMembers.
+func (bh *BotHandler) SyncStreaks(now time.Time) error {
+	cfg := bh.config()
+	history := bh.Tracker.History
+	if history == nil || bh.paused() {
+		return nil
+	}
+	calendar := aoc.CalendarFor(cfg.AOCYear)
+	records := history.Members(cfg.AOCYear)
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var errs []error
+	var reached []milestone
+	for _, memberID := range ids {
+		record := records[memberID]
+		userID, linked := bh.linkedUser(memberID)
+		loc := bh.streakLocation(cfg, userID)
+
+		streak := streaks.Current(calendar, record.Completed, loc, now)
+		length := streaks.Milestone(cfg.StreakMilestones, streak.Length)
+		if length > 0 && (length > record.Milestone || streak.Start != record.MilestoneStart) {
+			reached = append(reached, milestone{memberID: memberID, name: record.Name, streak: streak, milestone: length})
+		}
+
+		if !linked || cfg.StreakNudge.Duration == 0 {
+			continue
+		}
+		day, deadline, ok := streaks.AtRisk(calendar, record.Completed, loc, now, cfg.StreakNudge.Duration)
+		if ok && record.Nudged != day {
+			if err := bh.nudge(cfg, history, memberID, userID, streak, day, deadline); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if bh.streakYears == nil {
+		bh.streakYears = make(map[int]bool)
+	}
+	baseline := !bh.streakYears[cfg.AOCYear]
+	bh.streakYears[cfg.AOCYear] = true
+	if err := bh.announceMilestones(cfg, history, reached, baseline); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// milestone is a streak milestone a member has reached but that has not been
+// announced yet.
+type milestone struct {
+	memberID  int
+	name      string
+	streak    streaks.Streak
+	milestone int
+}
+
+// announceMilestones posts the milestones reached in one sync, as few
+// messages as fit them, and records them as announced. A baseline only
+// records them.
+func (bh *BotHandler) announceMilestones(cfg *config.Config, history *streaks.History, reached []milestone, baseline bool) error {
+	if baseline {
+		if len(reached) > 0 {
+			log.Printf("Recording %d streak milestones reached before the bot started", len(reached))
+		}
+		return recordMilestones(cfg, history, reached)
+	}
+
+	var errs []error
+	var message string
+	var pending []milestone
+	send := func() {
+		if err := bh.SendChannelMessage(bh.AnnouncementChannel(), message); err != nil {
+			errs = append(errs, fmt.Errorf("error announcing %d streak milestones: %w", len(pending), err))
+		} else {
+			errs = append(errs, recordMilestones(cfg, history, pending))
+		}
+		message, pending = "", nil
+	}
+	for _, m := range reached {
+		line := bh.Messages().Render(messages.StreakMilestone, messages.Data{Member: m.name, Count: m.streak.Length})
+		if len(pending) > 0 && len(message)+1+len(line) > maxMessageLength {
+			send()
+		}
+		if len(pending) > 0 {
+			message += "\n"
+		}
+		message += line
+		pending = append(pending, m)
+		log.Printf("Announcing the %d-day streak of %s", m.streak.Length, m.name)
+	}
+	if len(pending) > 0 {
+		send()
+	}
+	return errors.Join(errs...)
+}
+
+// recordMilestones remembers that milestones have been announced.
+func recordMilestones(cfg *config.Config, history *streaks.History, reached []milestone) error {
+	var errs []error
+	for _, m := range reached {
+		err := history.Update(cfg.AOCYear, m.memberID, func(r *streaks.Record) {
+			r.Milestone = m.milestone
+			r.MilestoneStart = m.streak.Start
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// linkedUser returns the Discord user who linked a member, if any.
+func (bh *BotHandler) linkedUser(memberID int) (string, bool) {
+	if bh.Profiles == nil {
+		return "", false
+	}
+	return bh.Profiles.UserForMember(memberID)
+}
+
+// streakLocation returns the timezone a member's streak days are counted in:
+// their own with STREAK_ZONE=member if they have set one, and otherwise nil
+// for the unlock timezone.
+func (bh *BotHandler) streakLocation(cfg *config.Config, userID string) *time.Location {
+	if cfg.StreakZone != config.StreakZoneMember || userID == "" || bh.Profiles == nil {
+		return nil
+	}
+	profile, ok := bh.Profiles.Get(userID)
+	if !ok {
+		return nil
+	}
+	return profile.Location()
+}
+
+func (bh *BotHandler) nudge(cfg *config.Config, history *streaks.History, memberID int, userID string, streak streaks.Streak, day int, deadline time.Time) error {
+	message := bh.Messages().Render(messages.StreakNudge, messages.Data{
+		Mention: "<@" + userID + ">",
+		Count:   streak.Length,
+		Day:     day,
+		Time:    deadline,
+	})
+	if err := bh.SendChannelMessage(bh.AnnouncementChannel(), message); err != nil {
+		return fmt.Errorf("error nudging %s: %w", userID, err)
+	}
+	return history.Update(cfg.AOCYear, memberID, func(r *streaks.Record) {
+		r.Nudged = day
+	})
+}
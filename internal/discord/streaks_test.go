@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/streaks"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTransport accepts every message sent to Discord and keeps its
+// content.
+type recordingTransport struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var message discordgo.MessageSend
+	if req.Body != nil {
+		if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+			return nil, err
+		}
+	}
+	rt.mu.Lock()
+	rt.messages = append(rt.messages, message.Content)
+	rt.mu.Unlock()
+
+	body, err := json.Marshal(discordgo.Message{ID: "message", Content: message.Content})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+		Request:    req,
+	}, nil
+}
+
+// completeDays records a member completing days 1 to last of 2024 on time.
+func completeDays(t *testing.T, history *streaks.History, memberID, last int) {
+	completed := make(map[int]time.Time)
+	for day := 1; day <= last; day++ {
+		completed[day] = time.Date(2024, time.December, day, 6, 0, 0, 0, time.UTC)
+	}
+	err := history.Update(2024, memberID, func(r *streaks.Record) {
+		r.Name = fmt.Sprintf("Member%d", memberID)
+		r.Completed = completed
+	})
+	assert.NoError(t, err, "Recording the history should not return an error")
+}
+
+func TestSyncStreaksBatchesMilestones(t *testing.T) {
+	session, err := discordgo.New("Bot test-token")
+	assert.NoError(t, err, "Creating the session should not return an error")
+	transport := &recordingTransport{}
+	session.Client = &http.Client{Transport: transport}
+
+	cfg := &config.Config{ChannelID: "announcements", AOCYear: 2024, Locale: "en", Timezone: "UTC", StreakMilestones: []int{5}}
+	tracker := leaderboard.NewTracker(cfg, nil, nil)
+	tracker.History = streaks.NewHistory(t.TempDir())
+	bh := NewBotHandler(context.Background(), session, tracker, cfg)
+	now := time.Date(2024, time.December, 5, 12, 0, 0, 0, time.UTC)
+
+	for memberID := 1; memberID <= 10; memberID++ {
+		completeDays(t, tracker.History, memberID, 5)
+	}
+	assert.NoError(t, bh.SyncStreaks(now), "The first sync should not return an error")
+	assert.Empty(t, transport.messages, "Streaks already reached when the bot starts should not be announced")
+	record, _ := tracker.History.Get(2024, 1)
+	assert.Equal(t, 5, record.Milestone, "The first sync should record the milestones already reached")
+
+	completeDays(t, tracker.History, 11, 5)
+	completeDays(t, tracker.History, 12, 5)
+	assert.NoError(t, bh.SyncStreaks(now), "The second sync should not return an error")
+
+	assert.Len(t, transport.messages, 1, "The milestones of a sync should be announced in one message")
+	if len(transport.messages) == 1 {
+		assert.Contains(t, transport.messages[0], "Member11", "Every new milestone should be announced")
+		assert.Contains(t, transport.messages[0], "Member12", "Every new milestone should be announced")
+		assert.NotContains(t, transport.messages[0], "Member1 ", "Milestones should only be announced once")
+	}
+}
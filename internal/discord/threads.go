@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/threads"
+	"github.com/bwmarrin/discordgo"
+)
+
+// threadArchiveMinutes is how long a discussion thread may be idle before
+// Discord archives it.
+const threadArchiveMinutes = 24 * 60
+
+// SyncThreads makes sure the current puzzle day has a discussion thread and
+// posts the results into the threads of days that are over. In spoiler mode
+// linked members who have the current day's first star are added to its
+// thread. A thread missed while the bot was down is opened, or closed, on the
+// next sync.
+func (bh *BotHandler) SyncThreads(ctx context.Context, now time.Time) error {
+	cfg := bh.config()
+	if cfg.DayThreads == "" || cfg.DayThreads == config.ThreadsOff || bh.Threads == nil {
+		return nil
+	}
+	year := cfg.AOCYear
+	day := aoc.CalendarFor(year).CurrentDay(now)
+
+	var errs []error
+	for _, open := range bh.Threads.Open(year) {
+		if open != day {
+			if err := bh.closeThread(year, open); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if day == 0 {
+		return errors.Join(errs...)
+	}
+
+	thread, ok := bh.Threads.Get(year, day)
+	if !ok {
+		var err error
+		if thread, err = bh.openThread(ctx, cfg, year, day); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+	}
+	if cfg.DayThreads == config.ThreadsSpoiler && !thread.Closed {
+		if err := bh.addSolvers(year, day, thread); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openThread starts the discussion thread of a puzzle day in the bot's
+// channel. Spoiler threads are private, so only members the bot adds can
+// read them.
+func (bh *BotHandler) openThread(ctx context.Context, cfg *config.Config, year, day int) (threads.Thread, error) {
+	bh.fetchTitles(ctx, year, []int{day})
+	threadType := discordgo.ChannelTypeGuildPublicThread
+	if cfg.DayThreads == config.ThreadsSpoiler {
+		threadType = discordgo.ChannelTypeGuildPrivateThread
+	}
+	channel, err := bh.Session.ThreadStartComplex(bh.AnnouncementChannel(), &discordgo.ThreadStart{
+		Name:                bh.Messages().Render(messages.ThreadName, messages.Data{Day: day, Title: bh.cachedTitle(year, day)}),
+		AutoArchiveDuration: threadArchiveMinutes,
+		Type:                threadType,
+	})
+	if err != nil {
+		return threads.Thread{}, fmt.Errorf("error starting thread for day %d: %w", day, err)
+	}
+	log.Printf("Opened thread %s for day %d", channel.ID, day)
+
+	thread := threads.Thread{ID: channel.ID}
+	if err := bh.Threads.Update(year, day, func(t *threads.Thread) { *t = thread }); err != nil {
+		return thread, err
+	}
+	return thread, nil
+}
+
+// addSolvers adds the linked members who have the first star of the day to
+// its thread.
+func (bh *BotHandler) addSolvers(year, day int, thread threads.Thread) error {
+	current := bh.Tracker.Snapshot().Current
+	if current == nil || bh.Profiles == nil {
+		return nil
+	}
+
+	var errs []error
+	for userID, profile := range bh.Profiles.All() {
+		if !profile.Linked() || thread.HasMember(userID) {
+			continue
+		}
+		member, ok := current.Members[strconv.Itoa(profile.MemberID)]
+		if !ok {
+			continue
+		}
+		level, ok := member.CompletionDayLevels[strconv.Itoa(day)]
+		if !ok || level.Level1 == nil {
+			continue
+		}
+
+		if err := bh.Session.ThreadMemberAdd(thread.ID, userID); err != nil {
+			errs = append(errs, fmt.Errorf("error adding %s to thread %s: %w", userID, thread.ID, err))
+			continue
+		}
+		err := bh.Threads.Update(year, day, func(t *threads.Thread) {
+			t.Members = append(t.Members, userID)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeThread posts the solve times of a day that is over into its thread.
+// The thread is closed even if the results cannot be posted, so that a
+// message Discord rejects is not sent again on every sync.
+func (bh *BotHandler) closeThread(year, day int) error {
+	thread, ok := bh.Threads.Get(year, day)
+	if !ok || thread.Closed {
+		return nil
+	}
+
+	var errs []error
+	summary := bh.format().FormatSolveTimes(bh.Tracker.Snapshot().Current, day, bh.config().Location())
+	if err := bh.SendChannelMessage(thread.ID, summary); err != nil {
+		errs = append(errs, fmt.Errorf("error posting the results of day %d: %w", day, err))
+	}
+	log.Printf("Closed thread %s for day %d", thread.ID, day)
+	if err := bh.Threads.Update(year, day, func(t *threads.Thread) { t.Closed = true }); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/threads"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingTransport fails every request, like a Discord that is down or
+// rejects every message.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("discord is down")
+}
+
+func TestCloseThreadWhenSummaryFails(t *testing.T) {
+	session, err := discordgo.New("Bot test-token")
+	assert.NoError(t, err, "Creating the session should not return an error")
+	session.Client = &http.Client{Transport: failingTransport{}}
+	session.MaxRestRetries = 0
+
+	cfg := &config.Config{ChannelID: "announcements", AOCYear: 2024, Locale: "en", Timezone: "UTC"}
+	bh := NewBotHandler(context.Background(), session, leaderboard.NewTracker(cfg, nil, nil), cfg)
+	bh.Threads = threads.NewStore(t.TempDir())
+	assert.NoError(t, bh.Threads.Update(2024, 1, func(t *threads.Thread) { t.ID = "thread" }))
+
+	err = bh.closeThread(2024, 1)
+
+	assert.Error(t, err, "A summary that could not be posted should be reported")
+	thread, _ := bh.Threads.Get(2024, 1)
+	assert.True(t, thread.Closed, "The thread should be closed so the summary is not retried forever")
+}
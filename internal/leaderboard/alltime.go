@@ -0,0 +1,114 @@
+package leaderboard
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Standing is a member's total over several events. Name is the one they had
+// in the latest of them.
+type Standing struct {
+	MemberID int
+	Name     string
+	Stars    int
+	Score    int
+	Events   int
+}
+
+// AllTime adds up the stars and local scores of each member, by AoC user ID,
+// over the given leaderboards. The standings are sorted by points if byPoints
+// is set and by stars otherwise, the other total breaking ties.
+func AllTime(leaderboards []*aoc.Leaderboard, byPoints bool) []Standing {
+	sorted := make([]*aoc.Leaderboard, 0, len(leaderboards))
+	for _, leaderboard := range leaderboards {
+		if leaderboard != nil {
+			sorted = append(sorted, leaderboard)
+		}
+	}
+	// Oldest first, so that later names replace earlier ones.
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := strconv.Atoi(sorted[i].Event)
+		b, _ := strconv.Atoi(sorted[j].Event)
+		return a < b
+	})
+
+	totals := make(map[int]*Standing)
+	for _, leaderboard := range sorted {
+		for _, member := range leaderboard.Members {
+			standing, ok := totals[member.ID]
+			if !ok {
+				standing = &Standing{MemberID: member.ID}
+				totals[member.ID] = standing
+			}
+			standing.Name = member.Name
+			standing.Stars += member.Stars
+			standing.Score += member.LocalScore
+			standing.Events++
+		}
+	}
+
+	standings := make([]Standing, 0, len(totals))
+	for _, standing := range totals {
+		standings = append(standings, *standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		first, second := [2]int{a.Stars, a.Score}, [2]int{b.Stars, b.Score}
+		if byPoints {
+			first, second = [2]int{a.Score, a.Stars}, [2]int{b.Score, b.Stars}
+		}
+		if first != second {
+			if first[0] != second[0] {
+				return first[0] > second[0]
+			}
+			return first[1] > second[1]
+		}
+		return a.Name < b.Name
+	})
+	return standings
+}
+
+// FormatAllTime formats all-time standings with the default messages.
+func FormatAllTime(standings []Standing, byPoints bool) *discordgo.MessageEmbed {
+	return defaultFormatter.FormatAllTime(standings, byPoints)
+}
+
+// FormatAllTime formats standings as sorted by AllTime. Members level on the
+// total they are sorted by share a rank.
+func (f *Formatter) FormatAllTime(standings []Standing, byPoints bool) *discordgo.MessageEmbed {
+	if len(standings) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	var prevTotal, rank int
+	for i, standing := range standings {
+		total := standing.Stars
+		if byPoints {
+			total = standing.Score
+		}
+		if i == 0 || total < prevTotal {
+			rank = i + 1
+			prevTotal = total
+		}
+		sb.WriteString(f.Messages.Render(messages.AllTimeLine, messages.Data{
+			Rank:   rank,
+			Member: standing.Name,
+			Score:  standing.Score,
+			Stars:  standing.Stars,
+			Count:  standing.Events,
+		}))
+		sb.WriteString("\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       f.Messages.Render(messages.AllTimeTitle, messages.Data{}),
+		Description: sb.String(),
+		Color:       f.Messages.Color(messages.LeaderboardColor),
+	}
+}
@@ -0,0 +1,62 @@
+// internal/leaderboard/alltime_test.go
+
+package leaderboard
+
+import (
+	"testing"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func allTimeLeaderboards() []*aoc.Leaderboard {
+	return []*aoc.Leaderboard{
+		{
+			Event: "2022",
+			Members: map[string]aoc.Member{
+				"1": {ID: 1, Name: "Alice", LocalScore: 100, Stars: 50},
+				"2": {ID: 2, Name: "Bob", LocalScore: 300, Stars: 20},
+			},
+		},
+		{
+			Event: "2023",
+			Members: map[string]aoc.Member{
+				"1": {ID: 1, Name: "Alice Smith", LocalScore: 200, Stars: 40},
+				"3": {ID: 3, Name: "Charlie", LocalScore: 50, Stars: 10},
+			},
+		},
+		nil,
+	}
+}
+
+func TestAllTime(t *testing.T) {
+	standings := AllTime(allTimeLeaderboards(), false)
+
+	assert.Equal(t, []Standing{
+		{MemberID: 1, Name: "Alice Smith", Stars: 90, Score: 300, Events: 2},
+		{MemberID: 2, Name: "Bob", Stars: 20, Score: 300, Events: 1},
+		{MemberID: 3, Name: "Charlie", Stars: 10, Score: 50, Events: 1},
+	}, standings, "Totals should be summed by member ID and sorted by stars")
+}
+
+func TestAllTime_ByPoints(t *testing.T) {
+	standings := AllTime(allTimeLeaderboards(), true)
+
+	names := make([]string, len(standings))
+	for i, standing := range standings {
+		names[i] = standing.Name
+	}
+	assert.Equal(t, []string{"Alice Smith", "Bob", "Charlie"}, names, "Ties on points should be broken by stars")
+}
+
+func TestFormatAllTime(t *testing.T) {
+	embed := FormatAllTime(AllTime(allTimeLeaderboards(), true), true)
+
+	assert.NotNil(t, embed, "Embed should not be nil")
+	assert.Equal(t, "AoC All-Time Standings:", embed.Title, "Embed title should match")
+	expected := "1. Alice Smith - 90 stars, 300 points in 2 events\n" +
+		"1. Bob - 20 stars, 300 points in 1 event\n" +
+		"3. Charlie - 10 stars, 50 points in 1 event\n"
+	assert.Equal(t, expected, embed.Description, "Members level on points should share a rank")
+	assert.Nil(t, FormatAllTime(nil, false), "Embed should be nil without standings")
+}
@@ -0,0 +1,183 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+)
+
+// Stage is a step of an update cycle. Stages run in the order they are
+// declared.
+type Stage int
+
+const (
+	// StageFetch downloads the leaderboard. On failure the tracker state is
+	// left untouched.
+	StageFetch Stage = iota
+	// StageValidate checks that the fetched leaderboard belongs to the
+	// configured event and leaderboard. On failure it is discarded.
+	StageValidate
+	// StageDiff compares the fetched leaderboard with the current one and
+	// installs it. Without a comparable current leaderboard the fetched one
+	// is recorded as a baseline, and only a summary is announced.
+	StageDiff
+	// StageNotify announces the changes. On failure the new state is kept,
+	// so that a retry cannot announce the same events twice.
+	StageNotify
+	// StagePersist saves the new state and adds it to the star history. On
+	// failure the state is kept in memory and saved again by the next cycle.
+	StagePersist
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageFetch:
+		return "fetch"
+	case StageValidate:
+		return "validate"
+	case StageDiff:
+		return "diff"
+	case StageNotify:
+		return "notify"
+	case StagePersist:
+		return "persist"
+	}
+	return fmt.Sprintf("Stage(%d)", int(s))
+}
+
+// CycleError reports the stage at which an update cycle failed.
+type CycleError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("update cycle failed at %s stage: %v", e.Stage, e.Err)
+}
+
+func (e *CycleError) Unwrap() error {
+	return e.Err
+}
+
+// Changes describes what an update cycle found. NewStars names each member
+// that earned stars, while Stars lists every star earned. Leaderboard is the
+// leaderboard the changes were computed against, and Previous the one it was
+// compared with. Baseline is set when there
+// was no comparable previous leaderboard, in which case the lists are empty
+// and the leaderboard should be announced as a summary instead.
+type Changes struct {
+	NewStars    []string
+	NewMembers  []string
+	Stars       []StarEvent
+	RankChanges []RankChange
+	Leaderboard *aoc.Leaderboard
+	Previous    *aoc.Leaderboard
+	Baseline    bool
+}
+
+// HasUpdates reports whether any stars or members were added.
+func (c Changes) HasUpdates() bool {
+	return len(c.NewStars) > 0 || len(c.NewMembers) > 0
+}
+
+// Refresh runs one update cycle through the fetch, validate, diff, notify and
+// persist stages. notify is only called when there is something to announce,
+// which includes a new baseline.
+// A failure is returned as a *CycleError naming the stage. Cycles are
+// serialized, so a concurrent call waits until the running one has finished,
+// or returns ctx's error if it is cancelled first.
+func (t *Tracker) Refresh(ctx context.Context, notify func(Changes) error) (Changes, error) {
+	select {
+	case t.cycle <- struct{}{}:
+	case <-ctx.Done():
+		return Changes{}, ctx.Err()
+	}
+	defer func() { <-t.cycle }()
+
+	t.mu.Lock()
+	t.LastUpdate = time.Now()
+	t.mu.Unlock()
+
+	fetched, err := t.GetLeaderboardContext(ctx)
+	if err != nil {
+		return Changes{}, t.fail(StageFetch, err)
+	}
+
+	if err := validateLeaderboard(fetched, t.config()); err != nil {
+		return Changes{}, t.fail(StageValidate, err)
+	}
+
+	t.mu.Lock()
+	aliased := withAliases(fetched, t.aliases)
+	changes := diffLeaderboards(withAliases(t.CurrentLeaderboard, t.aliases), aliased)
+	t.install(fetched)
+	t.LastError = nil
+	recordChanges(t.Config.LeaderboardID, changes)
+	t.mu.Unlock()
+
+	var errs []error
+	if changes.Baseline {
+		log.Printf("No comparable previous leaderboard, recording a baseline")
+	}
+	if (changes.Baseline || changes.HasUpdates()) && notify != nil {
+		if err := notify(changes); err != nil {
+			errs = append(errs, &CycleError{Stage: StageNotify, Err: err})
+		}
+	}
+
+	if t.Store != nil {
+		if err := t.Store.Save(fetched); err != nil {
+			errs = append(errs, &CycleError{Stage: StagePersist, Err: err})
+		}
+	}
+	if t.History != nil {
+		if err := t.History.Add(aliased); err != nil {
+			errs = append(errs, &CycleError{Stage: StagePersist, Err: err})
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		t.mu.Lock()
+		t.LastError = err
+		t.mu.Unlock()
+		return changes, err
+	}
+	return changes, nil
+}
+
+// fail records a cycle failure that happened before the state was changed.
+func (t *Tracker) fail(stage Stage, err error) error {
+	cycleErr := &CycleError{Stage: stage, Err: err}
+	t.mu.Lock()
+	t.LastError = cycleErr
+	t.mu.Unlock()
+	return cycleErr
+}
+
+// validateLeaderboard rejects leaderboards that cannot be the one the bot is
+// configured to track.
+func validateLeaderboard(leaderboard *aoc.Leaderboard, cfg *config.Config) error {
+	return leaderboard.Check(cfg.AOCYear, cfg.LeaderboardID)
+}
+
+// diffLeaderboards works out what changed from previous to current. When
+// previous is missing or belongs to another event or private leaderboard,
+// e.g. after LEADERBOARD_ID was changed, current becomes a baseline.
+func diffLeaderboards(previous, current *aoc.Leaderboard) Changes {
+	if previous == nil || previous.Event != current.Event || previous.OwnerID != current.OwnerID {
+		return Changes{Leaderboard: current, Baseline: true}
+	}
+	return Changes{
+		NewStars:    newStars(previous, current),
+		NewMembers:  newMembers(previous, current),
+		Stars:       starEvents(previous, current),
+		RankChanges: rankChanges(previous, current),
+		Leaderboard: current,
+		Previous:    previous,
+	}
+}
@@ -0,0 +1,285 @@
+// internal/leaderboard/cycle_test.go
+
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/streaks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func cycleTestConfig() *config.Config {
+	return &config.Config{
+		LeaderboardID: "12345",
+		AOCYear:       2024,
+	}
+}
+
+func cycleTestLeaderboard(event string, ownerID int, members map[string]aoc.Member) *aoc.Leaderboard {
+	return &aoc.Leaderboard{
+		Members: members,
+		Event:   event,
+		OwnerID: ownerID,
+	}
+}
+
+func TestRefresh_ValidationFailures(t *testing.T) {
+	members := map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2},
+	}
+	initial := cycleTestLeaderboard("2024", 12345, members)
+
+	tests := []struct {
+		name    string
+		fetched *aoc.Leaderboard
+	}{
+		{"Wrong Event", cycleTestLeaderboard("2023", 12345, members)},
+		{"No Members", cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{})},
+		{"Wrong Owner", cycleTestLeaderboard("2024", 99999, members)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockAOCClient)
+			mockClient.On("GetLeaderboard", "12345").Return(tt.fetched, nil)
+			tracker := NewTracker(cycleTestConfig(), initial, mockClient)
+
+			notified := false
+			_, err := tracker.Refresh(context.Background(), func(Changes) error {
+				notified = true
+				return nil
+			})
+
+			var cycleErr *CycleError
+			assert.ErrorAs(t, err, &cycleErr, "Expected a cycle error")
+			assert.Equal(t, StageValidate, cycleErr.Stage, "Expected the validate stage to fail")
+			assert.Equal(t, initial, tracker.Snapshot().Current, "Current leaderboard should be kept")
+			assert.False(t, notified, "Nothing should be announced")
+			assert.Equal(t, err, tracker.Status().LastError, "The failure should be recorded")
+		})
+	}
+}
+
+func TestRefresh_FetchFailureKeepsState(t *testing.T) {
+	initial := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(nil, errors.New("API error"))
+	tracker := NewTracker(cycleTestConfig(), initial, mockClient)
+
+	_, err := tracker.Refresh(context.Background(), nil)
+
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr, "Expected a cycle error")
+	assert.Equal(t, StageFetch, cycleErr.Stage, "Expected the fetch stage to fail")
+	assert.Equal(t, initial, tracker.Snapshot().Current, "Current leaderboard should be kept")
+	assert.Nil(t, tracker.Snapshot().Previous, "Previous leaderboard should not be touched")
+}
+
+func TestRefresh_CancelledWhileWaiting(t *testing.T) {
+	mockClient := new(MockAOCClient)
+	tracker := NewTracker(cycleTestConfig(), nil, mockClient)
+	tracker.cycle <- struct{}{} // another cycle is running
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := tracker.Refresh(ctx, nil)
+
+	assert.ErrorIs(t, err, context.Canceled, "A cancelled cycle should stop waiting")
+	mockClient.AssertNotCalled(t, "GetLeaderboard", "12345")
+}
+
+func TestRefresh_BaselineWithoutCurrentLeaderboard(t *testing.T) {
+	fetched := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2},
+		"2": {ID: 2, Name: "User2", Stars: 4},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(fetched, nil)
+	tracker := NewTracker(cycleTestConfig(), nil, mockClient)
+
+	var notified []Changes
+	changes, err := tracker.Refresh(context.Background(), func(c Changes) error {
+		notified = append(notified, c)
+		return nil
+	})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, changes.Baseline, "The first leaderboard should be recorded as a baseline")
+	assert.False(t, changes.HasUpdates(), "A baseline should not report any new members or stars")
+	assert.Equal(t, []Changes{changes}, notified, "The baseline should be announced once")
+	assert.Equal(t, fetched, tracker.Snapshot().Current, "The fetched leaderboard should be installed")
+}
+
+func TestRefresh_BaselineOnEventChange(t *testing.T) {
+	previous := cycleTestLeaderboard("2023", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 50},
+	})
+	fetched := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 0},
+		"2": {ID: 2, Name: "User2", Stars: 2},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(fetched, nil)
+	tracker := NewTracker(cycleTestConfig(), previous, mockClient)
+
+	changes, err := tracker.Refresh(context.Background(), nil)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, changes.Baseline, "A new event should start a new baseline")
+	assert.Empty(t, changes.NewMembers, "Members of the new event should not be announced as new")
+}
+
+func TestRefresh_BaselineOnLeaderboardChange(t *testing.T) {
+	previous := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 10},
+	})
+	fetched := cycleTestLeaderboard("2024", 67890, map[string]aoc.Member{
+		"2": {ID: 2, Name: "User2", Stars: 8},
+		"3": {ID: 3, Name: "User3", Stars: 4},
+	})
+	cfg := cycleTestConfig()
+	cfg.LeaderboardID = "67890"
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "67890").Return(fetched, nil)
+	tracker := NewTracker(cfg, previous, mockClient)
+
+	changes, err := tracker.Refresh(context.Background(), nil)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, changes.Baseline, "Another leaderboard should start a new baseline")
+	assert.Empty(t, changes.NewMembers, "Members of the new leaderboard should not be announced as new")
+	assert.Empty(t, changes.Stars, "Stars of the new leaderboard should not be announced")
+}
+
+func TestRefresh_Metrics(t *testing.T) {
+	unlock := int(aoc.UnlockTime(2024, 1).Unix())
+	previous := cycleTestLeaderboard("2023", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 50},
+	})
+	baseline := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 0},
+		"2": {ID: 2, Name: "User2", Stars: 0},
+	})
+	fetched := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 1, CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+			"1": {Level1: star(unlock + 60)},
+		}},
+		"2": {ID: 2, Name: "User2", Stars: 0},
+		"3": {ID: 3, Name: "User3", Stars: 0},
+	})
+	stars := metrics.StarsDetected.WithLabelValues("12345")
+	members := metrics.MembersDetected.WithLabelValues("12345")
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(baseline, nil).Once()
+	mockClient.On("GetLeaderboard", "12345").Return(fetched, nil).Once()
+	tracker := NewTracker(cycleTestConfig(), previous, mockClient)
+
+	before := [2]float64{testutil.ToFloat64(stars), testutil.ToFloat64(members)}
+	_, err := tracker.Refresh(context.Background(), nil)
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, before[0], testutil.ToFloat64(stars), "A baseline should not count any stars")
+	assert.Equal(t, before[1], testutil.ToFloat64(members), "A baseline should not count any members")
+
+	_, err = tracker.Refresh(context.Background(), nil)
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, before[0]+1, testutil.ToFloat64(stars), "The new star should be counted")
+	assert.Equal(t, before[1]+1, testutil.ToFloat64(members), "The new member should be counted")
+}
+
+func TestRefresh_NotifyFailureKeepsNewState(t *testing.T) {
+	previous := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2},
+	})
+	fetched := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 3},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(fetched, nil)
+	tracker := NewTracker(cycleTestConfig(), previous, mockClient)
+	tracker.Store = NewStore(t.TempDir(), 1)
+
+	changes, err := tracker.Refresh(context.Background(), func(Changes) error {
+		return errors.New("discord error")
+	})
+
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr, "Expected a cycle error")
+	assert.Equal(t, StageNotify, cycleErr.Stage, "Expected the notify stage to fail")
+	assert.Equal(t, []string{"User1"}, changes.NewStars, "Changes should still be reported")
+	assert.Equal(t, fetched, tracker.Snapshot().Current, "The fetched leaderboard should be kept")
+
+	stored, _, loadErr := tracker.Store.Load()
+	assert.NoError(t, loadErr, "The leaderboard should still be persisted")
+	assert.Equal(t, fetched, stored, "The fetched leaderboard should be persisted")
+}
+
+func TestRefresh_PersistFailure(t *testing.T) {
+	previous := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(previous, nil)
+	tracker := NewTracker(cycleTestConfig(), previous, mockClient)
+
+	// Use a regular file as the data directory so that saving fails
+	dataDir := filepath.Join(t.TempDir(), "not-a-dir")
+	assert.NoError(t, os.WriteFile(dataDir, nil, 0o644))
+	tracker.Store = NewStore(dataDir, 1)
+
+	_, err := tracker.Refresh(context.Background(), nil)
+
+	var cycleErr *CycleError
+	assert.ErrorAs(t, err, &cycleErr, "Expected a cycle error")
+	assert.Equal(t, StagePersist, cycleErr.Stage, "Expected the persist stage to fail")
+	assert.Equal(t, previous, tracker.Snapshot().Current, "The fetched leaderboard should stay in memory")
+}
+
+func TestRefresh_RecordsHistory(t *testing.T) {
+	fetched := cycleTestLeaderboard("2024", 12345, map[string]aoc.Member{
+		"1": {ID: 1, Name: "User1", Stars: 2, CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+			"1": {Level1: &aoc.StarDetail{GetStarTs: 100}, Level2: &aoc.StarDetail{GetStarTs: 200}},
+		}},
+	})
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "12345").Return(fetched, nil)
+	tracker := NewTracker(cycleTestConfig(), nil, mockClient)
+	tracker.History = streaks.NewHistory(t.TempDir())
+
+	_, err := tracker.Refresh(context.Background(), nil)
+
+	assert.NoError(t, err, "Expected no error")
+	record, ok := tracker.History.Get(2024, 1)
+	assert.True(t, ok, "The member should be recorded in the history")
+	assert.Contains(t, record.Completed, 1, "The completed day should be recorded")
+}
+
+func TestCheckForNewStars_NilLeaderboards(t *testing.T) {
+	tracker := NewTracker(cycleTestConfig(), nil, new(MockAOCClient))
+
+	newStars, err := tracker.CheckForNewStars()
+	assert.NoError(t, err, "Expected no error")
+	assert.Empty(t, newStars, "Expected no new stars without leaderboards")
+
+	newMembers, err := tracker.CheckForNewMembers()
+	assert.NoError(t, err, "Expected no error")
+	assert.Empty(t, newMembers, "Expected no new members without leaderboards")
+}
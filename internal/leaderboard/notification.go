@@ -0,0 +1,349 @@
+package leaderboard
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+// StarEvent is a star a member earned between two leaderboards. Elapsed is
+// how long after the puzzle unlocked it was earned.
+type StarEvent struct {
+	Member  string
+	Day     int
+	Part    int
+	Time    time.Time
+	Elapsed time.Duration
+}
+
+// RankChange is a member moving on the leaderboard. Ranks start at 1.
+type RankChange struct {
+	Member string
+	From   int
+	To     int
+}
+
+// Discord rejects embeds with more fields, longer field values or more
+// characters in all than this, and messages longer than maxMessageLength.
+const (
+	maxEmbedFields     = 25
+	maxEmbedFieldValue = 1024
+	maxEmbedLength     = 6000
+	maxMessageLength   = 2000
+)
+
+// FormatChanges composes changes into an embed with the default messages.
+func FormatChanges(changes Changes) *discordgo.MessageEmbed {
+	return defaultFormatter.FormatChanges(changes)
+}
+
+// FormatChanges composes the events of one or more update cycles into a
+// single embed: stars grouped by day and part, rank changes and new members.
+// It returns nil if there is nothing to announce.
+func (f *Formatter) FormatChanges(changes Changes) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+
+	year := 0
+	if changes.Leaderboard != nil {
+		year, _ = strconv.Atoi(changes.Leaderboard.Event)
+	}
+	for _, day := range groupStarsByDay(changes.Stars) {
+		var lines []string
+		for part, stars := range day.parts {
+			if len(stars) == 0 {
+				continue
+			}
+			entries := make([]string, 0, len(stars))
+			for _, star := range stars {
+				entries = append(entries, f.Messages.Render(messages.StarEntry, messages.Data{
+					Member:  star.Member,
+					Time:    star.Time.In(f.location()),
+					Elapsed: star.Elapsed,
+				}))
+			}
+			lines = append(lines, f.Messages.Render(messages.PartLine, messages.Data{
+				Day:     day.day,
+				Part:    part + 1,
+				Members: entries,
+			}))
+		}
+		heading := f.Messages.Render(messages.DayHeading, messages.Data{Day: day.day, Title: f.title(year, day.day)})
+		fields = append(fields, embedField(heading, lines))
+	}
+	if len(changes.Stars) == 0 && len(changes.NewStars) > 0 {
+		// The leaderboard did not say which stars were earned.
+		var lines []string
+		for _, member := range changes.NewStars {
+			lines = append(lines, f.Messages.Render(messages.NewStar, messages.Data{Member: member}))
+		}
+		fields = append(fields, embedField(f.Messages.Render(messages.NewStarsHeading, messages.Data{}), lines))
+	}
+
+	if len(changes.RankChanges) > 0 {
+		var lines []string
+		for _, change := range changes.RankChanges {
+			name := messages.RankUp
+			if change.To > change.From {
+				name = messages.RankDown
+			}
+			lines = append(lines, f.Messages.Render(name, messages.Data{
+				Member: change.Member,
+				From:   change.From,
+				To:     change.To,
+				Delta:  abs(change.From - change.To),
+			}))
+		}
+		fields = append(fields, embedField(f.Messages.Render(messages.RankChangesHeading, messages.Data{}), lines))
+	}
+
+	if len(changes.NewMembers) > 0 {
+		var lines []string
+		for _, member := range changes.NewMembers {
+			lines = append(lines, f.Messages.Render(messages.NewMember, messages.Data{Member: member}))
+		}
+		fields = append(fields, embedField(f.Messages.Render(messages.NewMembersHeading, messages.Data{}), lines))
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) > maxEmbedFields {
+		// Keep the latest days, rank changes and new members.
+		fields = fields[len(fields)-maxEmbedFields:]
+	}
+	title := f.Messages.Render(messages.UpdatesTitle, messages.Data{})
+	for len(fields) > 1 && embedLength(title, fields) > maxEmbedLength {
+		fields = fields[1:]
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:  title,
+		Fields: fields,
+		Color:  f.Messages.Color(messages.UpdatesColor),
+	}
+}
+
+// embedLength counts the characters of an embed's title and fields, which
+// Discord limits to maxEmbedLength.
+func embedLength(title string, fields []*discordgo.MessageEmbedField) int {
+	length := utf8.RuneCountInString(title)
+	for _, field := range fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return length
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// embedField builds a field of lines, cut short with an ellipsis if it is
+// too long. It is cut between runes, so that no character is split.
+func embedField(name string, lines []string) *discordgo.MessageEmbedField {
+	value := strings.Join(lines, "\n")
+	if len(value) > maxEmbedFieldValue {
+		cut := maxEmbedFieldValue - len("\n…")
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+		value = value[:cut] + "\n…"
+	}
+	return &discordgo.MessageEmbedField{Name: name, Value: value}
+}
+
+type dayStars struct {
+	day   int
+	parts [2][]StarEvent
+}
+
+// groupStarsByDay groups star events by day and part, keeping the order in
+// which the stars were earned within each part.
+func groupStarsByDay(stars []StarEvent) []dayStars {
+	sorted := make([]StarEvent, len(stars))
+	copy(sorted, stars)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Day != sorted[j].Day {
+			return sorted[i].Day < sorted[j].Day
+		}
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var days []dayStars
+	for _, star := range sorted {
+		if star.Part < 1 || star.Part > 2 {
+			continue
+		}
+		if len(days) == 0 || days[len(days)-1].day != star.Day {
+			days = append(days, dayStars{day: star.Day})
+		}
+		day := &days[len(days)-1]
+		day.parts[star.Part-1] = append(day.parts[star.Part-1], star)
+	}
+	return days
+}
+
+// starEvents lists the stars earned in current that previous did not have.
+// Members that are new to the leaderboard are included.
+func starEvents(previous, current *aoc.Leaderboard) []StarEvent {
+	var events []StarEvent
+	if previous == nil || current == nil {
+		return events
+	}
+
+	year, _ := strconv.Atoi(current.Event)
+	for memberID, member := range current.Members {
+		previousMember := previous.Members[memberID]
+		for dayKey, level := range member.CompletionDayLevels {
+			day, err := strconv.Atoi(dayKey)
+			if err != nil {
+				continue
+			}
+			previousLevel := previousMember.CompletionDayLevels[dayKey]
+			if level.Level1 != nil && previousLevel.Level1 == nil {
+				events = append(events, starEvent(member.Name, year, day, 1, level.Level1))
+			}
+			if level.Level2 != nil && previousLevel.Level2 == nil {
+				events = append(events, starEvent(member.Name, year, day, 2, level.Level2))
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		return events[i].Member < events[j].Member
+	})
+	return events
+}
+
+func starEvent(member string, year, day, part int, detail *aoc.StarDetail) StarEvent {
+	earned := time.Unix(int64(detail.GetStarTs), 0)
+	return StarEvent{
+		Member:  member,
+		Day:     day,
+		Part:    part,
+		Time:    earned,
+		Elapsed: earned.Sub(aoc.UnlockTime(year, day)),
+	}
+}
+
+// ranks returns each member's rank by local score, with tied members sharing
+// a rank as in FormatLeaderboard.
+func ranks(leaderboard *aoc.Leaderboard) map[string]int {
+	ids := make([]string, 0, len(leaderboard.Members))
+	for id := range leaderboard.Members {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return leaderboard.Members[ids[i]].LocalScore > leaderboard.Members[ids[j]].LocalScore
+	})
+
+	result := make(map[string]int, len(ids))
+	rank := 0
+	for i, id := range ids {
+		if i == 0 || leaderboard.Members[id].LocalScore < leaderboard.Members[ids[i-1]].LocalScore {
+			rank = i + 1
+		}
+		result[id] = rank
+	}
+	return result
+}
+
+// rankChanges lists the members present on both leaderboards whose rank
+// changed, best new rank first.
+func rankChanges(previous, current *aoc.Leaderboard) []RankChange {
+	var changes []RankChange
+	if previous == nil || current == nil {
+		return changes
+	}
+
+	previousRanks := ranks(previous)
+	for id, rank := range ranks(current) {
+		previousRank, ok := previousRanks[id]
+		if !ok || previousRank == rank {
+			continue
+		}
+		changes = append(changes, RankChange{
+			Member: current.Members[id].Name,
+			From:   previousRank,
+			To:     rank,
+		})
+	}
+
+	sortRankChanges(changes)
+	return changes
+}
+
+func sortRankChanges(changes []RankChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].To != changes[j].To {
+			return changes[i].To < changes[j].To
+		}
+		return changes[i].Member < changes[j].Member
+	})
+}
+
+// Digest accumulates the changes of several update cycles so that they can be
+// announced together on a schedule. It is safe for concurrent use.
+type Digest struct {
+	pending Changes
+	mu      sync.Mutex
+}
+
+// Add merges changes into the digest. A member that moves several times is
+// reported once, from their first rank to their latest one.
+func (d *Digest) Add(changes Changes) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending.NewStars = append(d.pending.NewStars, changes.NewStars...)
+	d.pending.NewMembers = append(d.pending.NewMembers, changes.NewMembers...)
+	d.pending.Stars = append(d.pending.Stars, changes.Stars...)
+	if changes.Leaderboard != nil {
+		d.pending.Leaderboard = changes.Leaderboard
+	}
+
+	merged := make([]RankChange, 0, len(d.pending.RankChanges)+len(changes.RankChanges))
+	latest := make(map[string]int, len(changes.RankChanges))
+	for _, change := range changes.RankChanges {
+		latest[change.Member] = change.To
+	}
+	for _, change := range d.pending.RankChanges {
+		if to, ok := latest[change.Member]; ok {
+			change.To = to
+			delete(latest, change.Member)
+		}
+		if change.From != change.To {
+			merged = append(merged, change)
+		}
+	}
+	for _, change := range changes.RankChanges {
+		if _, ok := latest[change.Member]; ok {
+			merged = append(merged, change)
+		}
+	}
+	sortRankChanges(merged)
+	d.pending.RankChanges = merged
+}
+
+// Flush returns the accumulated changes and empties the digest. ok is false
+// if there is nothing to announce.
+func (d *Digest) Flush() (changes Changes, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changes = d.pending
+	d.pending = Changes{}
+	return changes, changes.HasUpdates()
+}
@@ -0,0 +1,313 @@
+// internal/leaderboard/notification_test.go
+
+package leaderboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func star(ts int) *aoc.StarDetail {
+	return &aoc.StarDetail{GetStarTs: ts}
+}
+
+func TestStarEvents(t *testing.T) {
+	day1 := int(aoc.UnlockTime(2024, 1).Unix())
+	day2 := int(aoc.UnlockTime(2024, 2).Unix())
+	previous := &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 100)},
+			}},
+		},
+	}
+	current := &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 100), Level2: star(day1 + 300)},
+				"2": {Level1: star(day2 + 60)},
+			}},
+			"2": {ID: 2, Name: "Bob", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 200)},
+			}},
+		},
+	}
+
+	events := starEvents(previous, current)
+
+	expected := []StarEvent{
+		{Member: "Bob", Day: 1, Part: 1, Time: time.Unix(int64(day1+200), 0), Elapsed: 200 * time.Second},
+		{Member: "Alice", Day: 1, Part: 2, Time: time.Unix(int64(day1+300), 0), Elapsed: 300 * time.Second},
+		{Member: "Alice", Day: 2, Part: 1, Time: time.Unix(int64(day2+60), 0), Elapsed: time.Minute},
+	}
+	assert.Equal(t, expected, events, "Should list every new star in the order it was earned, with the time since the unlock")
+	assert.Empty(t, starEvents(nil, current), "Should not report stars without a previous leaderboard")
+}
+
+func TestRankChanges(t *testing.T) {
+	previous := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", LocalScore: 30},
+			"2": {ID: 2, Name: "Bob", LocalScore: 20},
+			"3": {ID: 3, Name: "Charlie", LocalScore: 10},
+		},
+	}
+	current := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", LocalScore: 30},
+			"2": {ID: 2, Name: "Bob", LocalScore: 20},
+			"3": {ID: 3, Name: "Charlie", LocalScore: 40},
+			"4": {ID: 4, Name: "Dana", LocalScore: 5},
+		},
+	}
+
+	changes := rankChanges(previous, current)
+
+	expected := []RankChange{
+		{Member: "Charlie", From: 3, To: 1},
+		{Member: "Alice", From: 1, To: 2},
+		{Member: "Bob", From: 2, To: 3},
+	}
+	assert.Equal(t, expected, changes, "Should list members whose rank changed, best new rank first")
+}
+
+func TestRankChanges_Ties(t *testing.T) {
+	previous := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", LocalScore: 30},
+			"2": {ID: 2, Name: "Bob", LocalScore: 20},
+		},
+	}
+	current := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", LocalScore: 30},
+			"2": {ID: 2, Name: "Bob", LocalScore: 30},
+		},
+	}
+
+	changes := rankChanges(previous, current)
+
+	assert.Equal(t, []RankChange{{Member: "Bob", From: 2, To: 1}}, changes, "Tied members should share a rank")
+}
+
+func TestFormatChanges(t *testing.T) {
+	changes := Changes{
+		NewStars:   []string{"Alice", "Bob"},
+		NewMembers: []string{"Dana"},
+		Stars: []StarEvent{
+			{Member: "Alice", Day: 2, Part: 1, Time: time.Unix(300, 0), Elapsed: 5 * time.Minute},
+			{Member: "Bob", Day: 1, Part: 1, Time: time.Unix(100, 0), Elapsed: 100 * time.Second},
+			{Member: "Alice", Day: 1, Part: 1, Time: time.Unix(200, 0), Elapsed: 200 * time.Second},
+			{Member: "Alice", Day: 1, Part: 2, Time: time.Unix(250, 0), Elapsed: time.Hour + 250*time.Second},
+		},
+		RankChanges: []RankChange{
+			{Member: "Alice", From: 2, To: 1},
+			{Member: "Bob", From: 1, To: 2},
+		},
+	}
+
+	embed := FormatChanges(changes)
+
+	assert.NotNil(t, embed, "Embed should not be nil")
+	assert.Equal(t, "AoC Updates:", embed.Title, "Embed title should match")
+	if assert.Len(t, embed.Fields, 4, "Should have a field per day, rank changes and new members") {
+		assert.Equal(t, "Day 1 🌟", embed.Fields[0].Name, "Days should be in order")
+		assert.Equal(t, "Part 1: Bob (1m 40s), Alice (3m 20s)\nPart 2: Alice (1h 04m 10s)", embed.Fields[0].Value, "Stars should be grouped by part")
+		assert.Equal(t, "Day 2 🌟", embed.Fields[1].Name, "Days should be in order")
+		assert.Equal(t, "Part 1: Alice (5m 00s)", embed.Fields[1].Value, "Stars should be grouped by part")
+		assert.Equal(t, "Rank changes", embed.Fields[2].Name, "Rank changes should follow the stars")
+		assert.Equal(t, "⬆️ Alice 2 → 1\n⬇️ Bob 1 → 2", embed.Fields[2].Value, "Rank changes should match")
+		assert.Equal(t, "CHALLENGER APPROACHING!", embed.Fields[3].Name, "New members should come last")
+		assert.Equal(t, "Dana has joined the leaderboard!", embed.Fields[3].Value, "New members should match")
+	}
+}
+
+func TestFormatChanges_PuzzleTitles(t *testing.T) {
+	f := NewFormatter(messages.Default(), time.UTC)
+	f.Titles = func(year, day int) string {
+		if year == 2023 && day == 7 {
+			return "Camel Cards"
+		}
+		return ""
+	}
+
+	embed := f.FormatChanges(Changes{
+		Leaderboard: &aoc.Leaderboard{Event: "2023"},
+		Stars: []StarEvent{
+			{Member: "Alice", Day: 7, Part: 1},
+			{Member: "Alice", Day: 8, Part: 1},
+		},
+	})
+
+	if assert.NotNil(t, embed, "Embed should not be nil") && assert.Len(t, embed.Fields, 2) {
+		assert.Equal(t, "Day 7: Camel Cards 🌟", embed.Fields[0].Name, "Known titles should be shown")
+		assert.Equal(t, "Day 8 🌟", embed.Fields[1].Name, "Unknown titles should be left out")
+	}
+}
+
+func TestFormatChanges_NoChanges(t *testing.T) {
+	assert.Nil(t, FormatChanges(Changes{}), "Embed should be nil when nothing changed")
+}
+
+func TestFormatChanges_StarsWithoutDetails(t *testing.T) {
+	embed := FormatChanges(Changes{NewStars: []string{"Alice"}})
+
+	if assert.NotNil(t, embed, "Embed should not be nil") && assert.Len(t, embed.Fields, 1) {
+		assert.Equal(t, "Alice got a star! 🌟", embed.Fields[0].Value, "Should fall back to the member names")
+	}
+}
+
+func TestFormatChanges_LongField(t *testing.T) {
+	var stars []StarEvent
+	for i := 0; i < 200; i++ {
+		stars = append(stars, StarEvent{Member: "SomeoneWithALongName", Day: 1, Part: 1, Time: time.Unix(int64(i), 0)})
+	}
+
+	embed := FormatChanges(Changes{Stars: stars})
+
+	assert.LessOrEqual(t, len(embed.Fields[0].Value), maxEmbedFieldValue, "Field values should fit in an embed")
+}
+
+func TestFormatChanges_TotalLength(t *testing.T) {
+	// Thirty members earning both stars of every day, enough to fill each
+	// day's field.
+	var stars []StarEvent
+	for day := 1; day <= 25; day++ {
+		for part := 1; part <= 2; part++ {
+			for i := 0; i < 30; i++ {
+				stars = append(stars, StarEvent{Member: fmt.Sprintf("Member%02d", i), Day: day, Part: part, Time: time.Unix(int64(day*100+i), 0)})
+			}
+		}
+	}
+	leaderboard := &aoc.Leaderboard{Event: "2024"}
+
+	embed := FormatChanges(Changes{Stars: stars, Leaderboard: leaderboard})
+
+	length := embedLength(embed.Title, embed.Fields)
+	assert.LessOrEqual(t, length, maxEmbedLength, "The embed should fit in Discord's total limit")
+	kept := len(embed.Fields)
+	assert.True(t, strings.HasPrefix(embed.Fields[kept-1].Name, "Day 25"), "The latest day should be kept")
+
+	var dropped []StarEvent
+	for _, star := range stars {
+		if star.Day == 25-kept {
+			dropped = append(dropped, star)
+		}
+	}
+	previous := FormatChanges(Changes{Stars: dropped, Leaderboard: leaderboard}).Fields[0]
+	assert.Greater(t, length+embedLength("", []*discordgo.MessageEmbedField{previous}), maxEmbedLength, "Only the fields that do not fit should be dropped")
+}
+
+func TestEmbedField_CutsBetweenRunes(t *testing.T) {
+	field := embedField("Stars", []string{"a" + strings.Repeat("🌟", 400)})
+
+	assert.True(t, utf8.ValidString(field.Value), "Field values should not be cut inside a character")
+	assert.LessOrEqual(t, len(field.Value), maxEmbedFieldValue, "Field values should fit in an embed")
+	assert.True(t, strings.HasSuffix(field.Value, "🌟\n…"), "A long field should end with an ellipsis after a whole character")
+}
+
+func TestDigest(t *testing.T) {
+	var digest Digest
+
+	_, ok := digest.Flush()
+	assert.False(t, ok, "An empty digest should have nothing to announce")
+
+	digest.Add(Changes{
+		NewStars:    []string{"Alice"},
+		Stars:       []StarEvent{{Member: "Alice", Day: 1, Part: 1}},
+		RankChanges: []RankChange{{Member: "Alice", From: 3, To: 2}, {Member: "Bob", From: 2, To: 3}},
+	})
+	digest.Add(Changes{
+		NewStars:    []string{"Bob"},
+		NewMembers:  []string{"Dana"},
+		Stars:       []StarEvent{{Member: "Bob", Day: 1, Part: 1}},
+		RankChanges: []RankChange{{Member: "Alice", From: 2, To: 1}, {Member: "Bob", From: 3, To: 2}, {Member: "Charlie", From: 1, To: 3}},
+	})
+
+	changes, ok := digest.Flush()
+
+	assert.True(t, ok, "The digest should have something to announce")
+	assert.Equal(t, []string{"Alice", "Bob"}, changes.NewStars, "New stars should be accumulated")
+	assert.Equal(t, []string{"Dana"}, changes.NewMembers, "New members should be accumulated")
+	assert.Len(t, changes.Stars, 2, "Star events should be accumulated")
+	expectedRanks := []RankChange{
+		{Member: "Alice", From: 3, To: 1},
+		{Member: "Charlie", From: 1, To: 3},
+	}
+	assert.Equal(t, expectedRanks, changes.RankChanges, "Rank changes should be merged from the first to the latest rank")
+
+	_, ok = digest.Flush()
+	assert.False(t, ok, "Flush should empty the digest")
+}
+
+func TestFormatSolveTimes(t *testing.T) {
+	unlock := int(aoc.UnlockTime(2024, 1).Unix())
+	leaderboardData := &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(unlock + 600), Level2: star(unlock + 1500)},
+			}},
+			"2": {ID: 2, Name: "Bob", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(unlock + 300)},
+			}},
+		},
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+
+	times := FormatSolveTimes(leaderboardData, 1, paris)
+
+	expected := "Day 1 solve times, unlocked <t:1733029200:R>\n" +
+		"Bob - part 1: 5m 00s after unlock (Dec 1, 06:05 CET)\n" +
+		"Alice - part 1: 10m 00s after unlock (Dec 1, 06:10 CET)\n" +
+		"Alice - part 2: 25m 00s after unlock (Dec 1, 06:25 CET)"
+	assert.Equal(t, expected, times, "Solve times should be sorted by part and time, in the given timezone")
+}
+
+func TestFormatSolveTimes_LargeLeaderboard(t *testing.T) {
+	unlock := int(aoc.UnlockTime(2024, 1).Unix())
+	members := make(map[string]aoc.Member)
+	for i := 1; i <= 100; i++ {
+		members[strconv.Itoa(i)] = aoc.Member{ID: i, Name: fmt.Sprintf("Member%03d", i), CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+			"1": {Level1: star(unlock + 60*i), Level2: star(unlock + 120*i)},
+		}}
+	}
+
+	times := FormatSolveTimes(&aoc.Leaderboard{Event: "2024", Members: members}, 1, nil)
+
+	assert.LessOrEqual(t, len(times), maxMessageLength, "Solve times should fit in one message")
+	assert.True(t, strings.HasPrefix(times, "Day 1 solve times"), "The heading should be kept")
+	assert.Regexp(t, `\n…and \d+ more stars$`, times, "The stars left out should be counted")
+	shown := strings.Count(times, "after unlock")
+	assert.True(t, strings.HasSuffix(times, fmt.Sprintf("…and %d more stars", 200-shown)), "Every star should be shown or counted")
+}
+
+func TestFormatSolveTimes_NoStars(t *testing.T) {
+	times := FormatSolveTimes(&aoc.Leaderboard{Event: "2024"}, 3, nil)
+
+	assert.Equal(t, "Nobody has solved day 3 yet", times, "Should say nobody has solved the day")
+}
+
+func TestLatestDay(t *testing.T) {
+	leaderboardData := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {CompletionDayLevels: map[string]aoc.CompletionDayLevel{"3": {Level1: star(1)}}},
+			"2": {CompletionDayLevels: map[string]aoc.CompletionDayLevel{"12": {Level1: star(1)}}},
+		},
+	}
+
+	assert.Equal(t, 12, LatestDay(leaderboardData), "Should return the latest day with a star")
+	assert.Equal(t, 0, LatestDay(nil), "Should return 0 without a leaderboard")
+}
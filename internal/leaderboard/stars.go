@@ -0,0 +1,216 @@
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+// daysPerWeek is how many days the star grid groups under one week header.
+// Weeks count from day 1, not from the day of the week.
+const daysPerWeek = 7
+
+// StarsOptions changes what the star grid shows. The zero value shows every
+// unlocked day in the wide layout, members sorted by local score.
+type StarsOptions struct {
+	// From and To limit the grid to a range of days. Zero means the first
+	// and the last unlocked day.
+	From, To int
+	// Compact uses one character per day instead of three.
+	Compact bool
+	// ByStars sorts members by stars instead of local score.
+	ByStars bool
+}
+
+// ParseDayRange parses a day or range of days such as "10" or "10-15".
+func ParseDayRange(s string) (from, to int, ok bool) {
+	first, last, isRange := strings.Cut(s, "-")
+	from, err := strconv.Atoi(first)
+	if err != nil || from < 1 {
+		return 0, 0, false
+	}
+	if !isRange {
+		return from, from, true
+	}
+	to, err = strconv.Atoi(last)
+	if err != nil || to < from {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// StarsDays returns the days the star grid shows: the unlocked days within
+// the chosen range. from is past to when none of them has unlocked.
+func (f *Formatter) StarsDays(leaderboard *aoc.Leaderboard, opts StarsOptions) (from, to int) {
+	from, to = 1, calendar(leaderboard).Unlocked(f.now())
+	if opts.From > from {
+		from = opts.From
+	}
+	if opts.To > 0 && opts.To < to {
+		to = opts.To
+	}
+	return from, to
+}
+
+// FormatStars formats the star grid of every unlocked day.
+func (f *Formatter) FormatStars(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
+	return f.FormatStarsWith(leaderboard, StarsOptions{})
+}
+
+// FormatStarsWith formats the star grid: a column for each unlocked day in
+// the chosen range, grouped by week, and a row for each member.
+func (f *Formatter) FormatStarsWith(leaderboard *aoc.Leaderboard, opts StarsOptions) *discordgo.MessageEmbed {
+	if leaderboard == nil {
+		return nil
+	}
+
+	members := sortedMembers(leaderboard)
+	if opts.ByStars {
+		sortByStars(members)
+	}
+
+	from, to := f.StarsDays(leaderboard, opts)
+
+	longestNameLength := 0
+	for _, member := range members {
+		if len(member.Name) > longestNameLength {
+			longestNameLength = len(member.Name)
+		}
+	}
+
+	header := f.Messages.Render(messages.StarsHeader, messages.Data{})
+	grid := starGrid{compact: opts.Compact}
+	lines := []string{grid.dayHeader(header, from, to)}
+	// Line the stars up with the day numbers after the header.
+	indent := strings.Repeat(" ", utf8.RuneCountInString(header)+1)
+	for _, member := range members {
+		lines = append(lines, indent+grid.row(member, from, to)+fmt.Sprintf(" %-*s", longestNameLength, member.Name))
+	}
+	// Week headers only help when there is more than one week to tell apart.
+	if from <= to && (from-1)/daysPerWeek != (to-1)/daysPerWeek {
+		lines = append([]string{grid.weekHeader(f.Messages, utf8.RuneCountInString(header)+1, from, to)}, lines...)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       f.Messages.Render(messages.StarsTitle, messages.Data{}),
+		Description: "```" + strings.Join(lines, "\n") + "```",
+		Color:       f.Messages.Color(messages.StarsColor),
+	}
+
+	return embed
+}
+
+// sortByStars orders members by stars, then local score and name.
+func sortByStars(members []aoc.Member) {
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Stars != members[j].Stars {
+			return members[i].Stars > members[j].Stars
+		}
+		if members[i].LocalScore != members[j].LocalScore {
+			return members[i].LocalScore > members[j].LocalScore
+		}
+		return members[i].Name < members[j].Name
+	})
+}
+
+// starGrid lays out the columns of the star grid. Wide cells are three
+// characters, compact ones a single character, and weeks are set apart by
+// an extra space.
+type starGrid struct {
+	compact bool
+}
+
+// weekStart reports whether day begins a new week after the first column.
+func weekStart(day, from int) bool {
+	return day > from && (day-1)%daysPerWeek == 0
+}
+
+func (g starGrid) cellWidth() int {
+	if g.compact {
+		return 1
+	}
+	return 3
+}
+
+func (g starGrid) dayHeader(header string, from, to int) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	if g.compact {
+		sb.WriteString(" ")
+	}
+	for day := from; day <= to; day++ {
+		if weekStart(day, from) {
+			sb.WriteString(" ")
+		}
+		if g.compact {
+			sb.WriteString(strconv.Itoa(day % 10))
+		} else {
+			sb.WriteString(fmt.Sprintf(" %2d", day))
+		}
+	}
+	return sb.String()
+}
+
+// weekHeader names each week above its first column, which starts offset
+// characters into the line. A name that would run into the previous one is
+// left out.
+func (g starGrid) weekHeader(msgs *messages.Set, offset, from, to int) string {
+	var sb strings.Builder
+	column := offset
+	length := 0
+	for day := from; day <= to; day++ {
+		if weekStart(day, from) {
+			column++
+		}
+		if day == from || weekStart(day, from) {
+			label := msgs.Render(messages.StarsWeek, messages.Data{Count: (day-1)/daysPerWeek + 1})
+			if column > length || length == 0 {
+				sb.WriteString(strings.Repeat(" ", column-length))
+				sb.WriteString(label)
+				length = column + utf8.RuneCountInString(label)
+			}
+		}
+		column += g.cellWidth()
+	}
+	return sb.String()
+}
+
+func (g starGrid) row(member aoc.Member, from, to int) string {
+	var sb strings.Builder
+	for day := from; day <= to; day++ {
+		if weekStart(day, from) {
+			sb.WriteString(" ")
+		}
+		stars := 0
+		if level, ok := member.CompletionDayLevels[strconv.Itoa(day)]; ok {
+			if level.Level1 != nil {
+				stars++
+			}
+			if level.Level2 != nil {
+				stars++
+			}
+		}
+
+		switch {
+		case stars == 2 && g.compact:
+			sb.WriteString("★")
+		case stars == 1 && g.compact:
+			sb.WriteString("☆")
+		case g.compact:
+			sb.WriteString("·")
+		case stars == 2:
+			sb.WriteString(" ★ ")
+		case stars == 1:
+			sb.WriteString(" ☆ ")
+		default:
+			sb.WriteString("   ")
+		}
+	}
+	return sb.String()
+}
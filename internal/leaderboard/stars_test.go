@@ -0,0 +1,103 @@
+// internal/leaderboard/stars_test.go
+
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func gridLeaderboard() *aoc.Leaderboard {
+	both := aoc.CompletionDayLevel{Level1: &aoc.StarDetail{GetStarTs: 1}, Level2: &aoc.StarDetail{GetStarTs: 2}}
+	first := aoc.CompletionDayLevel{Level1: &aoc.StarDetail{GetStarTs: 1}}
+	return &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {
+				ID:                  1,
+				Name:                "Alice",
+				LocalScore:          100,
+				Stars:               5,
+				CompletionDayLevels: map[string]aoc.CompletionDayLevel{"1": both, "2": both, "10": first},
+			},
+			"2": {
+				ID:                  2,
+				Name:                "Bob",
+				LocalScore:          120,
+				Stars:               2,
+				CompletionDayLevels: map[string]aoc.CompletionDayLevel{"9": both},
+			},
+		},
+	}
+}
+
+// gridTime is day 10 of the 2024 event: ten days have unlocked.
+var gridTime = time.Date(2024, 12, 10, 12, 0, 0, 0, time.UTC)
+
+func TestFormatStarsWith_NonContiguousDays(t *testing.T) {
+	embed := formatterAt(gridTime).FormatStarsWith(gridLeaderboard(), StarsOptions{})
+
+	expected := "```    W1                    W2\n" +
+		"Day  1  2  3  4  5  6  7   8  9 10\n" +
+		"                              ★     Bob  \n" +
+		"     ★  ★                        ☆  Alice```"
+	assert.Equal(t, expected, embed.Description, "Every unlocked day should have a column, grouped by week")
+}
+
+func TestFormatStarsWith_Compact(t *testing.T) {
+	embed := formatterAt(gridTime).FormatStarsWith(gridLeaderboard(), StarsOptions{Compact: true})
+
+	expected := "```    W1      W2\n" +
+		"Day 1234567 890\n" +
+		"    ······· ·★· Bob  \n" +
+		"    ★★····· ··☆ Alice```"
+	assert.Equal(t, expected, embed.Description, "Compact grid should use a character per day")
+}
+
+func TestFormatStarsWith_RangeAndSortByStars(t *testing.T) {
+	embed := formatterAt(gridTime).FormatStarsWith(gridLeaderboard(), StarsOptions{From: 7, To: 12, ByStars: true})
+
+	expected := "```    W1  W2\n" +
+		"Day  7   8  9 10\n" +
+		"               ☆  Alice\n" +
+		"            ★     Bob  ```"
+	assert.Equal(t, expected, embed.Description, "Only the unlocked days of the range should be shown, members sorted by stars")
+}
+
+func TestStarsDays(t *testing.T) {
+	f := formatterAt(gridTime)
+
+	from, to := f.StarsDays(gridLeaderboard(), StarsOptions{From: 7, To: 12})
+	assert.Equal(t, []int{7, 10}, []int{from, to}, "The range should be limited to the unlocked days")
+
+	from, to = f.StarsDays(gridLeaderboard(), StarsOptions{From: 20, To: 25})
+	assert.Greater(t, from, to, "A range past the unlocked days should be empty")
+
+	from, to = formatterAt(time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)).StarsDays(gridLeaderboard(), StarsOptions{})
+	assert.Greater(t, from, to, "Nothing should be shown before the event starts")
+}
+
+func TestParseDayRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to int
+		ok       bool
+	}{
+		{"10", 10, 10, true},
+		{"10-15", 10, 15, true},
+		{"15-10", 0, 0, false},
+		{"0", 0, 0, false},
+		{"ten", 0, 0, false},
+		{"10-", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		from, to, ok := ParseDayRange(tt.input)
+		assert.Equal(t, tt.ok, ok, "ok should match for %q", tt.input)
+		assert.Equal(t, tt.from, from, "from should match for %q", tt.input)
+		assert.Equal(t, tt.to, to, "to should match for %q", tt.input)
+	}
+}
@@ -0,0 +1,140 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const snapshotFile = "leaderboard.json"
+
+// Store persists leaderboard snapshots in a data directory. Every save keeps
+// the previous snapshots as numbered backups (leaderboard.json.1 is the most
+// recent), so a corrupt file can be recovered from on startup.
+type Store struct {
+	dir     string
+	backups int
+
+	mu sync.Mutex
+	// lastSaved holds the last snapshot written, so that saving an unchanged
+	// leaderboard does not push older snapshots out of the backups.
+	lastSaved []byte
+}
+
+func NewStore(dir string, backups int) *Store {
+	return &Store{dir: dir, backups: backups}
+}
+
+func (s *Store) path(generation int) string {
+	if generation == 0 {
+		return filepath.Join(s.dir, snapshotFile)
+	}
+	return filepath.Join(s.dir, fmt.Sprintf("%s.%d", snapshotFile, generation))
+}
+
+// Save writes the leaderboard atomically and rotates the backups. Saving the
+// same leaderboard twice in a row only updates the snapshot's time, which
+// records that it was still current, so that a quiet leaderboard is not
+// mistaken for a stale one on the next startup.
+func (s *Store) Save(leaderboard *aoc.Leaderboard) error {
+	if leaderboard == nil {
+		return errors.New("error storing leaderboard: leaderboard is nil")
+	}
+
+	leaderboardJson, err := json.Marshal(leaderboard)
+	if err != nil {
+		return fmt.Errorf("error marshalling leaderboard: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(leaderboardJson, s.lastSaved) {
+		now := time.Now()
+		err := os.Chtimes(s.path(0), now, now)
+		if err == nil {
+			return nil
+		}
+		// A snapshot removed behind our back is written again.
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error updating leaderboard file time: %w", err)
+		}
+	}
+	log.Println("Storing leaderboard")
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := s.rotate(); err != nil {
+		return err
+	}
+	if err := storage.WriteFileAtomic(s.path(0), leaderboardJson, 0o644); err != nil {
+		return fmt.Errorf("error writing leaderboard file: %w", err)
+	}
+	s.lastSaved = leaderboardJson
+	return nil
+}
+
+// rotate shifts every snapshot one generation back, dropping the oldest.
+func (s *Store) rotate() error {
+	if s.backups <= 0 {
+		return nil
+	}
+	for generation := s.backups - 1; generation >= 0; generation-- {
+		err := os.Rename(s.path(generation), s.path(generation+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error rotating leaderboard backups: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load returns the newest snapshot that can be read and parsed, together with
+// the time it was written. Corrupt snapshots are skipped.
+func (s *Store) Load() (*aoc.Leaderboard, time.Time, error) {
+	log.Println("Getting leaderboard from file")
+
+	var errs []error
+	for generation := 0; generation <= s.backups; generation++ {
+		leaderboard, savedAt, err := readSnapshot(s.path(generation))
+		if err == nil {
+			if generation > 0 {
+				log.Printf("recovered leaderboard from backup %s", s.path(generation))
+			}
+			return leaderboard, savedAt, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("skipping leaderboard snapshot: %v", err)
+		}
+		errs = append(errs, err)
+	}
+	return nil, time.Time{}, fmt.Errorf("no valid leaderboard snapshot found: %w", errors.Join(errs...))
+}
+
+func readSnapshot(path string) (*aoc.Leaderboard, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading leaderboard file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading leaderboard file: %w", err)
+	}
+
+	var lb aoc.Leaderboard
+	if err := json.Unmarshal(data, &lb); err != nil {
+		return nil, time.Time{}, fmt.Errorf("error unmarshalling leaderboard %s: %w", path, err)
+	}
+	if lb.Members == nil {
+		return nil, time.Time{}, fmt.Errorf("error reading leaderboard %s: no members", path)
+	}
+	return &lb, info.ModTime(), nil
+}
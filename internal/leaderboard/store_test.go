@@ -0,0 +1,122 @@
+// internal/leaderboard/store_test.go
+
+package leaderboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func storeTestLeaderboard(stars int) *aoc.Leaderboard {
+	return &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "User1", LocalScore: 100, Stars: stars},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+}
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	store := NewStore(t.TempDir(), 3)
+
+	err := store.Save(storeTestLeaderboard(1))
+	assert.NoError(t, err, "Save should succeed")
+
+	loaded, savedAt, err := store.Load()
+
+	assert.NoError(t, err, "Load should succeed")
+	assert.Equal(t, storeTestLeaderboard(1), loaded, "Loaded leaderboard should match the saved one")
+	assert.False(t, savedAt.IsZero(), "Load should report when the snapshot was saved")
+}
+
+func TestStoreSaveCreatesDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	store := NewStore(dir, 3)
+
+	err := store.Save(storeTestLeaderboard(1))
+
+	assert.NoError(t, err, "Save should create the data directory")
+	assert.FileExists(t, filepath.Join(dir, "leaderboard.json"), "Snapshot should be written to the data directory")
+}
+
+func TestStoreRotatesBackups(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 2)
+
+	for stars := 1; stars <= 4; stars++ {
+		assert.NoError(t, store.Save(storeTestLeaderboard(stars)))
+	}
+
+	for generation, stars := range []int{4, 3, 2} {
+		lb, _, err := readSnapshot(store.path(generation))
+		assert.NoError(t, err, "Snapshot generation %d should be readable", generation)
+		assert.Equal(t, stars, lb.Members["1"].Stars, "Snapshot generation %d should hold the expected save", generation)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "leaderboard.json.3"), "Only the configured number of backups should be kept")
+}
+
+func TestStoreSkipsUnchangedSave(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 2)
+
+	assert.NoError(t, store.Save(storeTestLeaderboard(1)))
+	assert.NoError(t, store.Save(storeTestLeaderboard(1)))
+
+	assert.NoFileExists(t, filepath.Join(dir, "leaderboard.json.1"), "Saving an unchanged leaderboard should not rotate the backups")
+}
+
+func TestStoreUnchangedSaveKeepsSnapshotCurrent(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 2)
+	assert.NoError(t, store.Save(storeTestLeaderboard(1)))
+	// Nothing changed for two days, with an update cycle saving the same
+	// leaderboard in the meantime.
+	twoDaysAgo := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(store.path(0), twoDaysAgo, twoDaysAgo))
+	assert.NoError(t, store.Save(storeTestLeaderboard(1)))
+
+	// The bot restarts.
+	_, savedAt, err := NewStore(dir, 2).Load()
+
+	assert.NoError(t, err, "Load should succeed")
+	assert.WithinDuration(t, time.Now(), savedAt, time.Minute, "A snapshot confirmed by the last cycle should not look stale")
+}
+
+func TestStoreLoadRecoversFromCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 2)
+
+	assert.NoError(t, store.Save(storeTestLeaderboard(1)))
+	assert.NoError(t, store.Save(storeTestLeaderboard(2)))
+
+	// Simulate a crash that left the newest snapshot truncated
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "leaderboard.json"), []byte(`{"members": {"1": {`), 0o644))
+
+	loaded, _, err := store.Load()
+
+	assert.NoError(t, err, "Load should fall back to a backup")
+	assert.Equal(t, 1, loaded.Members["1"].Stars, "Load should return the newest valid backup")
+}
+
+func TestStoreLoadNoSnapshot(t *testing.T) {
+	store := NewStore(t.TempDir(), 2)
+
+	loaded, _, err := store.Load()
+
+	assert.Error(t, err, "Load should fail when there is no snapshot")
+	assert.Nil(t, loaded, "Leaderboard should be nil when there is no snapshot")
+}
+
+func TestStoreSaveNil(t *testing.T) {
+	store := NewStore(t.TempDir(), 2)
+
+	err := store.Save(nil)
+
+	assert.Error(t, err, "Saving a nil leaderboard should fail")
+}
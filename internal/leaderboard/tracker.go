@@ -1,23 +1,62 @@
 package leaderboard
 
 import (
+	"context"
+
 	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
 	"github.com/PaytonWebber/aoc-discord-bot/internal/config"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/streaks"
 	"log"
+	"sync"
 	"time"
 )
 
 type AOCClient interface {
-	GetLeaderboard(leaderboardID string) (*aoc.Leaderboard, error)
+	GetLeaderboardContext(ctx context.Context, leaderboardID string) (*aoc.Leaderboard, error)
 }
 
-// Tracker manages the leaderboard state
+// Tracker manages the leaderboard state. It is safe for concurrent use: the
+// exported fields are guarded by an internal lock, so other packages should
+// read them through Snapshot or Status rather than directly.
 type Tracker struct {
 	PreviousLeaderboard *aoc.Leaderboard
 	CurrentLeaderboard  *aoc.Leaderboard
 	Client              AOCClient
 	Config              *config.Config
-	LastUpdate          time.Time
+	Store               *Store
+	// History keeps every member's completed days beyond the two
+	// leaderboards held here, for solve streaks.
+	History     *streaks.History
+	LastUpdate  time.Time
+	LastSuccess time.Time
+	LastError   error
+
+	mu sync.RWMutex
+	// aliases are names members are shown under instead of their AoC name,
+	// by member ID.
+	aliases map[int]string
+	// cycle serializes update cycles so that two can never overlap. A
+	// cycle holds its only slot while it runs, which lets a waiting one give
+	// up when its context is cancelled.
+	cycle chan struct{}
+}
+
+// Snapshot is a consistent view of the tracker state. The leaderboards it
+// refers to are never modified once the tracker has stored them, so a
+// snapshot can be read without holding any lock.
+type Snapshot struct {
+	Current    *aoc.Leaderboard
+	Previous   *aoc.Leaderboard
+	LastUpdate time.Time
+}
+
+// Status summarises the tracker state for health checks.
+type Status struct {
+	LastUpdate  time.Time
+	LastSuccess time.Time
+	LastError   error
+	MemberCount int
 }
 
 func NewTracker(cfg *config.Config, StoredLeaderboard *aoc.Leaderboard, client AOCClient) *Tracker {
@@ -25,11 +64,30 @@ func NewTracker(cfg *config.Config, StoredLeaderboard *aoc.Leaderboard, client A
 		Client:             client,
 		Config:             cfg,
 		CurrentLeaderboard: StoredLeaderboard,
+		cycle:              make(chan struct{}, 1),
 	}
 }
 
+// ApplyConfig swaps in a new configuration. The stored leaderboards are kept,
+// so a changed leaderboard or year takes effect on the next update.
+func (t *Tracker) ApplyConfig(cfg *config.Config) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Config = cfg
+}
+
+func (t *Tracker) config() *config.Config {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Config
+}
+
 func (t *Tracker) GetLeaderboard() (*aoc.Leaderboard, error) {
-	leaderboard, err := t.Client.GetLeaderboard(t.Config.LeaderboardID)
+	return t.GetLeaderboardContext(context.Background())
+}
+
+func (t *Tracker) GetLeaderboardContext(ctx context.Context) (*aoc.Leaderboard, error) {
+	leaderboard, err := t.Client.GetLeaderboardContext(ctx, t.config().LeaderboardID)
 	if err != nil {
 		return nil, err
 	}
@@ -38,23 +96,146 @@ func (t *Tracker) GetLeaderboard() (*aoc.Leaderboard, error) {
 }
 
 func (t *Tracker) UpdateLeaderboard() error {
-	leaderboard, err := t.GetLeaderboard()
+	return t.UpdateLeaderboardContext(context.Background())
+}
+
+// UpdateLeaderboardContext fetches the leaderboard and makes it the current
+// one. A cancelled ctx aborts the fetch and leaves the state untouched.
+func (t *Tracker) UpdateLeaderboardContext(ctx context.Context) error {
+	leaderboard, err := t.GetLeaderboardContext(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.LastError = err
 	if err != nil {
 		return err
 	}
 
+	t.install(leaderboard)
+
+	return nil
+}
+
+// install makes leaderboard the current one after a successful fetch. The
+// caller must hold t.mu.
+func (t *Tracker) install(leaderboard *aoc.Leaderboard) {
+	t.LastSuccess = time.Now()
 	t.PreviousLeaderboard = t.CurrentLeaderboard
 	t.CurrentLeaderboard = leaderboard
+	t.recordMetrics()
+}
 
-	return nil
+// recordMetrics updates the leaderboard metrics after a successful fetch.
+// The caller must hold t.mu.
+func (t *Tracker) recordMetrics() {
+	leaderboardID := t.Config.LeaderboardID
+	metrics.LastSuccessfulFetch.WithLabelValues(leaderboardID).Set(float64(t.LastSuccess.Unix()))
+	if t.CurrentLeaderboard == nil {
+		return
+	}
+	metrics.TrackedMembers.WithLabelValues(leaderboardID).Set(float64(len(t.CurrentLeaderboard.Members)))
+}
+
+// recordChanges counts the stars and members an update cycle found. A
+// baseline found nothing new, so it is not counted.
+func recordChanges(leaderboardID string, changes Changes) {
+	if changes.Baseline {
+		return
+	}
+	metrics.StarsDetected.WithLabelValues(leaderboardID).Add(float64(len(changes.Stars)))
+	metrics.MembersDetected.WithLabelValues(leaderboardID).Add(float64(len(changes.NewMembers)))
+}
+
+// Snapshot returns the current tracker state, with members shown under
+// their aliases.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return Snapshot{
+		Current:    withAliases(t.CurrentLeaderboard, t.aliases),
+		Previous:   withAliases(t.PreviousLeaderboard, t.aliases),
+		LastUpdate: t.LastUpdate,
+	}
+}
+
+// SetAliases sets the names members are shown under instead of their AoC
+// name, by member ID. The stored leaderboards keep the AoC names.
+func (t *Tracker) SetAliases(aliases map[int]string) {
+	copied := make(map[int]string, len(aliases))
+	for id, alias := range aliases {
+		copied[id] = alias
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.aliases = copied
+}
+
+// withAliases returns the leaderboard with members renamed to their aliases.
+// The leaderboard itself is left untouched, so it is returned as is when no
+// member has an alias and copied otherwise.
+func withAliases(leaderboard *aoc.Leaderboard, aliases map[int]string) *aoc.Leaderboard {
+	if leaderboard == nil || len(aliases) == 0 {
+		return leaderboard
+	}
+	var renamed *aoc.Leaderboard
+	for key, member := range leaderboard.Members {
+		alias, ok := aliases[member.ID]
+		if !ok || alias == member.Name {
+			continue
+		}
+		if renamed == nil {
+			copied := *leaderboard
+			copied.Members = make(map[string]aoc.Member, len(leaderboard.Members))
+			for k, m := range leaderboard.Members {
+				copied.Members[k] = m
+			}
+			renamed = &copied
+		}
+		member.Name = alias
+		renamed.Members[key] = member
+	}
+	if renamed == nil {
+		return leaderboard
+	}
+	return renamed
+}
+
+func (t *Tracker) Status() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := Status{
+		LastUpdate:  t.LastUpdate,
+		LastSuccess: t.LastSuccess,
+		LastError:   t.LastError,
+	}
+	if t.CurrentLeaderboard != nil {
+		status.MemberCount = len(t.CurrentLeaderboard.Members)
+	}
+	return status
 }
 
 func (t *Tracker) CheckForNewStars() ([]string, error) {
+	snapshot := t.Snapshot()
+	return newStars(snapshot.Previous, snapshot.Current), nil
+}
+
+func (t *Tracker) CheckForNewMembers() ([]string, error) {
+	snapshot := t.Snapshot()
+	return newMembers(snapshot.Previous, snapshot.Current), nil
+}
+
+func newStars(previous, current *aoc.Leaderboard) []string {
 	var newStars []string
+	if previous == nil || current == nil {
+		return newStars
+	}
 
 	// TODO: Get the new star data from the current leaderboard
-	for memberID, member := range t.CurrentLeaderboard.Members {
-		previousMember, ok := t.PreviousLeaderboard.Members[memberID]
+	for memberID, member := range current.Members {
+		previousMember, ok := previous.Members[memberID]
 		if !ok {
 			continue
 		} else if member.Stars > previousMember.Stars {
@@ -62,19 +243,22 @@ func (t *Tracker) CheckForNewStars() ([]string, error) {
 		}
 	}
 
-	return newStars, nil
+	return newStars
 }
 
-func (t *Tracker) CheckForNewMembers() ([]string, error) {
+func newMembers(previous, current *aoc.Leaderboard) []string {
 	var newMembers []string
+	if previous == nil || current == nil {
+		return newMembers
+	}
 
-	for memberID, member := range t.CurrentLeaderboard.Members {
-		_, ok := t.PreviousLeaderboard.Members[memberID]
+	for memberID, member := range current.Members {
+		_, ok := previous.Members[memberID]
 		if !ok {
 			log.Printf("New member: %s", member.Name)
 			newMembers = append(newMembers, member.Name)
 		}
 	}
 
-	return newMembers, nil
+	return newMembers
 }
@@ -3,7 +3,10 @@
 package leaderboard
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
@@ -25,6 +28,10 @@ func (m *MockAOCClient) GetLeaderboard(leaderboardID string) (*aoc.Leaderboard,
 	return args.Get(0).(*aoc.Leaderboard), args.Error(1)
 }
 
+func (m *MockAOCClient) GetLeaderboardContext(ctx context.Context, leaderboardID string) (*aoc.Leaderboard, error) {
+	return m.GetLeaderboard(leaderboardID)
+}
+
 func TestNewTracker(t *testing.T) {
 	cfg := &config.Config{
 		LeaderboardID: "test-leaderboard",
@@ -519,3 +526,167 @@ func TestCheckForNewMembers_PartialPreviousLeaderboard(t *testing.T) {
 	assert.Len(t, newMembers, 1, "Expected one new member")
 	assert.Contains(t, newMembers, "User2", "Expected User2 to be identified as a new member")
 }
+
+func TestRefresh(t *testing.T) {
+	previousLeaderboard := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "User1", LocalScore: 200, Stars: 2},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+
+	currentLeaderboard := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "User1", LocalScore: 210, Stars: 3},
+			"2": {ID: 2, Name: "User2", LocalScore: 50, Stars: 1},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+
+	cfg := &config.Config{LeaderboardID: "test-leaderboard"}
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "test-leaderboard").Return(currentLeaderboard, nil)
+	tracker := NewTracker(cfg, previousLeaderboard, mockClient)
+
+	var notified Changes
+	changes, err := tracker.Refresh(context.Background(), func(c Changes) error {
+		notified = c
+		return nil
+	})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, []string{"User1"}, changes.NewStars, "Expected User1 to have new stars")
+	assert.Equal(t, []string{"User2"}, changes.NewMembers, "Expected User2 to be a new member")
+	assert.Equal(t, currentLeaderboard, changes.Leaderboard, "Changes should refer to the fetched leaderboard")
+	assert.Equal(t, changes, notified, "Notify should receive the same changes")
+	assert.False(t, tracker.Snapshot().LastUpdate.IsZero(), "LastUpdate should be set")
+}
+
+func TestRefresh_Error(t *testing.T) {
+	cfg := &config.Config{LeaderboardID: "test-leaderboard"}
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "test-leaderboard").Return(nil, errors.New("API error"))
+	tracker := NewTracker(cfg, &aoc.Leaderboard{}, mockClient)
+
+	notified := false
+	_, err := tracker.Refresh(context.Background(), func(Changes) error {
+		notified = true
+		return nil
+	})
+
+	assert.Error(t, err, "Expected an error")
+	assert.False(t, notified, "Notify should not be called when the fetch fails")
+}
+
+// TestTrackerConcurrentAccess hammers the update and read paths at the same
+// time. Run it with -race to check that the tracker guards its state.
+func TestTrackerConcurrentAccess(t *testing.T) {
+	cfg := &config.Config{LeaderboardID: "test-leaderboard"}
+
+	leaderboards := []*aoc.Leaderboard{
+		{
+			Members: map[string]aoc.Member{
+				"1": {ID: 1, Name: "User1", LocalScore: 200, Stars: 2},
+			},
+			Event: "2024",
+		},
+		{
+			Members: map[string]aoc.Member{
+				"1": {ID: 1, Name: "User1", LocalScore: 210, Stars: 3},
+				"2": {ID: 2, Name: "User2", LocalScore: 50, Stars: 1},
+			},
+			Event: "2024",
+		},
+	}
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "test-leaderboard").Return(leaderboards[0], nil).Once()
+	mockClient.On("GetLeaderboard", "test-leaderboard").Return(leaderboards[1], nil)
+	tracker := NewTracker(cfg, leaderboards[0], mockClient)
+
+	var inCycle int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				tracker.Refresh(context.Background(), func(Changes) error {
+					if !atomic.CompareAndSwapInt32(&inCycle, 0, 1) {
+						t.Error("Update cycles should never overlap")
+					}
+					atomic.StoreInt32(&inCycle, 0)
+					return nil
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				snapshot := tracker.Snapshot()
+				FormatLeaderboard(snapshot.Current)
+				FormatStars(snapshot.Current)
+				tracker.Status()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				tracker.UpdateLeaderboard()
+				tracker.CheckForNewStars()
+				tracker.CheckForNewMembers()
+				tracker.ApplyConfig(cfg)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRefresh_PersistsLeaderboard(t *testing.T) {
+	cfg := &config.Config{LeaderboardID: "test-leaderboard"}
+
+	currentLeaderboard := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "User1", LocalScore: 210, Stars: 3},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+
+	mockClient := new(MockAOCClient)
+	mockClient.On("GetLeaderboard", "test-leaderboard").Return(currentLeaderboard, nil)
+	tracker := NewTracker(cfg, currentLeaderboard, mockClient)
+	tracker.Store = NewStore(t.TempDir(), 1)
+
+	_, err := tracker.Refresh(context.Background(), nil)
+	assert.NoError(t, err, "Expected no error")
+
+	stored, _, err := tracker.Store.Load()
+	assert.NoError(t, err, "Expected the leaderboard to be stored")
+	assert.Equal(t, currentLeaderboard, stored, "Stored leaderboard should match the fetched one")
+}
+
+func TestSetAliases(t *testing.T) {
+	stored := &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice"},
+			"2": {ID: 2, Name: ""},
+		},
+	}
+	tracker := NewTracker(&config.Config{}, stored, nil)
+
+	tracker.SetAliases(map[int]string{2: "Bob"})
+
+	current := tracker.Snapshot().Current
+	assert.Equal(t, "Alice", current.Members["1"].Name, "Members without an alias should keep their name")
+	assert.Equal(t, "Bob", current.Members["2"].Name, "Members should be shown under their alias")
+	assert.Equal(t, "", stored.Members["2"].Name, "The stored leaderboard should keep the AoC name")
+
+	tracker.SetAliases(nil)
+	assert.Same(t, stored, tracker.Snapshot().Current, "Without aliases the stored leaderboard should be returned")
+}
@@ -2,32 +2,107 @@ package leaderboard
 
 import (
 	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
 
-	"encoding/json"
-	"fmt"
-	"log"
-	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-func FormatLeaderboard(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
-	if leaderboard == nil || len(leaderboard.Members) == 0 {
-		return nil
+// Formatter renders leaderboards and updates using a set of message
+// templates. Absolute times are shown in Location, or UTC if it is nil.
+type Formatter struct {
+	Messages *messages.Set
+	Location *time.Location
+	// Titles looks up the title of a puzzle, returning "" if it is not
+	// known. Titles are left out if it is nil.
+	Titles func(year, day int) string
+	// Now tells which puzzles have unlocked. It is time.Now if nil.
+	Now func() time.Time
+}
+
+func NewFormatter(msgs *messages.Set, loc *time.Location) *Formatter {
+	return &Formatter{Messages: msgs, Location: loc}
+}
+
+var defaultFormatter = NewFormatter(messages.Default(), time.UTC)
+
+func (f *Formatter) title(year, day int) string {
+	if f.Titles == nil {
+		return ""
+	}
+	return f.Titles(year, day)
+}
+
+func (f *Formatter) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
 	}
+	return f.Now()
+}
+
+// calendar returns the calendar of the leaderboard's event.
+func calendar(leaderboard *aoc.Leaderboard) aoc.Calendar {
+	year, _ := strconv.Atoi(leaderboard.Event)
+	return aoc.CalendarFor(year)
+}
+
+func (f *Formatter) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+// FormatLeaderboard formats a leaderboard with the default messages.
+func FormatLeaderboard(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
+	return defaultFormatter.FormatLeaderboard(leaderboard)
+}
 
-	// Convert map to a slice for sorting
+// FormatBaseline formats a baseline summary with the default messages.
+func FormatBaseline(leaderboard *aoc.Leaderboard) string {
+	return defaultFormatter.FormatBaseline(leaderboard)
+}
+
+// FormatStars formats the star grid with the default messages.
+func FormatStars(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
+	return defaultFormatter.FormatStars(leaderboard)
+}
+
+// sortedMembers returns the members by local score. Ties are broken by stars
+// and then name, so that the order does not depend on map iteration.
+func sortedMembers(leaderboard *aoc.Leaderboard) []aoc.Member {
 	members := make([]aoc.Member, 0, len(leaderboard.Members))
 	for _, member := range leaderboard.Members {
 		members = append(members, member)
 	}
 
-	// Sort by local score
 	sort.Slice(members, func(i, j int) bool {
-		return members[i].LocalScore > members[j].LocalScore
+		if members[i].LocalScore != members[j].LocalScore {
+			return members[i].LocalScore > members[j].LocalScore
+		}
+		if members[i].Stars != members[j].Stars {
+			return members[i].Stars > members[j].Stars
+		}
+		return members[i].Name < members[j].Name
 	})
+	return members
+}
+
+// FormatSolveTimes lists the solve times of a day with the default messages.
+func FormatSolveTimes(leaderboard *aoc.Leaderboard, day int, loc *time.Location) string {
+	return defaultFormatter.FormatSolveTimes(leaderboard, day, loc)
+}
+
+func (f *Formatter) FormatLeaderboard(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
+	if leaderboard == nil || len(leaderboard.Members) == 0 {
+		return nil
+	}
+
+	members := sortedMembers(leaderboard)
 
 	var sb strings.Builder
 
@@ -39,125 +114,130 @@ func FormatLeaderboard(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
 			rank = i + 1
 			prevScore = member.LocalScore
 		}
-		line := fmt.Sprintf("%d. %s - %d points (%d stars)\n", rank, member.Name, member.LocalScore, member.Stars)
-		sb.WriteString(line)
+		sb.WriteString(f.Messages.Render(messages.LeaderboardLine, messages.Data{
+			Rank:   rank,
+			Member: member.Name,
+			Score:  member.LocalScore,
+			Stars:  member.Stars,
+		}))
+		sb.WriteString("\n")
 	}
 
 	// Create the embed
 	embed := &discordgo.MessageEmbed{
-		Title:       "AoC Leaderboard:",
+		Title:       f.Messages.Render(messages.LeaderboardTitle, messages.Data{}),
 		Description: sb.String(),
-		Color:       0x034F20,
+		Color:       f.Messages.Color(messages.LeaderboardColor),
 	}
 
 	return embed
 }
 
-func FormatStars(leaderboard *aoc.Leaderboard) *discordgo.MessageEmbed {
+// FormatBaseline summarises a leaderboard that has just started being tracked.
+func (f *Formatter) FormatBaseline(leaderboard *aoc.Leaderboard) string {
 	if leaderboard == nil {
-		return nil
+		return ""
 	}
 
-	// Convert map to a slice for sorting
-	members := make([]aoc.Member, 0, len(leaderboard.Members))
+	stars := 0
 	for _, member := range leaderboard.Members {
-		members = append(members, member)
+		stars += member.Stars
 	}
 
-	// Sort by local score
-	sort.Slice(members, func(i, j int) bool {
-		return members[i].LocalScore > members[j].LocalScore
-	})
-
-	var sb strings.Builder
-
-	maxDays := 0
-	longestNameLength := 0
-	for _, member := range members {
-		if len(member.CompletionDayLevels) > maxDays {
-			maxDays = len(member.CompletionDayLevels)
-		}
-		if len(member.Name) > longestNameLength {
-			longestNameLength = len(member.Name)
-		}
-	}
+	return f.Messages.Render(messages.Baseline, messages.Data{Count: len(leaderboard.Members), Stars: stars})
+}
 
-	sb.WriteString("Day")
-	for i := 1; i <= maxDays; i++ {
-		sb.WriteString(fmt.Sprintf(" %2d", i))
+// FormatSolveTimes lists when each star of a day was earned, both as the time
+// after the puzzle unlocked and as an absolute time in loc. A nil loc means
+// the formatter's location. The list is cut short to fit in one message.
+func (f *Formatter) FormatSolveTimes(leaderboard *aoc.Leaderboard, day int, loc *time.Location) string {
+	if loc == nil {
+		loc = f.location()
 	}
 
-	for _, member := range members {
-		sb.WriteString("\n")
-		sb.WriteString("    ")
-		for i := 1; i <= maxDays; i++ {
-			stars := 0
-			day, exists := member.CompletionDayLevels[fmt.Sprint(i)]
-			if exists {
-				if day.Level2 != nil {
-					stars++
-				}
-				if day.Level1 != nil {
-					stars++
-				}
+	var stars []StarEvent
+	year := 0
+	if leaderboard != nil {
+		year, _ = strconv.Atoi(leaderboard.Event)
+		dayKey := strconv.Itoa(day)
+		for _, member := range leaderboard.Members {
+			level, ok := member.CompletionDayLevels[dayKey]
+			if !ok {
+				continue
 			}
-
-			switch stars {
-			case 2:
-				sb.WriteString(" ★ ")
-			case 1:
-				sb.WriteString(" ☆ ")
-			default:
-				sb.WriteString("   ")
+			if level.Level1 != nil {
+				stars = append(stars, starEvent(member.Name, year, day, 1, level.Level1))
+			}
+			if level.Level2 != nil {
+				stars = append(stars, starEvent(member.Name, year, day, 2, level.Level2))
 			}
 		}
-		sb.WriteString(fmt.Sprintf(" %-*s", longestNameLength, member.Name))
 	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "AoC Stars:",
-		Description: "```" + sb.String() + "```",
-		Color:       0xB22222,
+	if len(stars) == 0 {
+		return f.Messages.Render(messages.TimesNone, messages.Data{Day: day})
 	}
 
-	return embed
-}
+	sort.Slice(stars, func(i, j int) bool {
+		if stars[i].Part != stars[j].Part {
+			return stars[i].Part < stars[j].Part
+		}
+		if stars[i].Elapsed != stars[j].Elapsed {
+			return stars[i].Elapsed < stars[j].Elapsed
+		}
+		return stars[i].Member < stars[j].Member
+	})
 
-func StoreLeaderboard(leaderboard *aoc.Leaderboard) error {
-	file, err := os.Create("leaderboard.json")
-	log.Println("Storing leaderboard")
-	if err != nil {
-		return fmt.Errorf("error creating leaderboard file: %w", err)
+	lines := []string{f.Messages.Render(messages.TimesHeading, messages.Data{
+		Day:   day,
+		Title: f.title(year, day),
+		Time:  aoc.UnlockTime(year, day).In(loc),
+	})}
+	for _, star := range stars {
+		lines = append(lines, f.Messages.Render(messages.TimesLine, messages.Data{
+			Member:  star.Member,
+			Part:    star.Part,
+			Time:    star.Time.In(loc),
+			Elapsed: star.Elapsed,
+		}))
 	}
+	return joinLines(lines, func(left int) string {
+		return f.Messages.Render(messages.TimesMore, messages.Data{Count: left})
+	})
+}
 
-	// change leadboard to json
-	leaderboardJson, err := json.Marshal(leaderboard)
-	if err != nil {
-		return fmt.Errorf("error marshalling leaderboard: %w", err)
+// joinLines joins lines into a message that fits in maxMessageLength bytes.
+// Lines that do not fit are left out, and replaced by the line more returns
+// for how many were left out.
+func joinLines(lines []string, more func(left int) string) string {
+	message := strings.Join(lines, "\n")
+	if len(message) <= maxMessageLength {
+		return message
 	}
-
-	// write leaderboard to file
-	_, err = file.Write(leaderboardJson)
-	if err != nil {
-		return fmt.Errorf("error writing leaderboard to file: %w", err)
+	kept, length := 0, 0
+	for kept < len(lines) && length+len(lines[kept])+1 <= maxMessageLength {
+		length += len(lines[kept]) + 1
+		kept++
 	}
-
-	return nil
+	for kept > 0 && length+len(more(len(lines)-kept)) > maxMessageLength {
+		kept--
+		length -= len(lines[kept]) + 1
+	}
+	return strings.Join(append(lines[:kept:kept], more(len(lines)-kept)), "\n")
 }
 
-func GetLeaderboardFromFile(file *os.File) (*aoc.Leaderboard, error) {
-	leaderboard, err := os.ReadFile("leaderboard.json")
-	log.Println("Getting leaderboard from file")
-	if err != nil {
-		return nil, fmt.Errorf("error reading leaderboard file: %w", err)
+// LatestDay returns the latest day anyone has earned a star on, or 0 if
+// nobody has.
+func LatestDay(leaderboard *aoc.Leaderboard) int {
+	latest := 0
+	if leaderboard == nil {
+		return latest
 	}
-
-	var lb aoc.Leaderboard
-
-	err = json.Unmarshal(leaderboard, &lb)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling leaderboard: %w", err)
+	for _, member := range leaderboard.Members {
+		for dayKey := range member.CompletionDayLevels {
+			if day, err := strconv.Atoi(dayKey); err == nil && day > latest {
+				latest = day
+			}
+		}
 	}
-
-	return &lb, nil
+	return latest
 }
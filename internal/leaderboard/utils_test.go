@@ -4,8 +4,10 @@ package leaderboard
 
 import (
 	"testing"
+	"time"
 
 	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,8 +115,8 @@ func TestFormatStars(t *testing.T) {
 		OwnerID: 12345,
 	}
 
-	// Call the function
-	embed := FormatStars(leaderboardData)
+	// Call the function on day 2, with two puzzles unlocked
+	embed := formatterAt(aoc.UnlockTime(2024, 2)).FormatStars(leaderboardData)
 
 	// Assertions
 	assert.NotNil(t, embed, "Embed should not be nil")
@@ -137,8 +139,8 @@ func TestFormatStars_EmptyLeaderboard(t *testing.T) {
 		OwnerID: 12345,
 	}
 
-	// Call the function
-	embed := FormatStars(leaderboardData)
+	// Call the function before the first puzzle unlocks
+	embed := formatterAt(time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)).FormatStars(leaderboardData)
 
 	// Assertions
 	assert.NotNil(t, embed, "Embed should not be nil even for empty leaderboard")
@@ -146,6 +148,29 @@ func TestFormatStars_EmptyLeaderboard(t *testing.T) {
 	assert.Equal(t, "```Day```", embed.Description, "Embed description should match expected formatted stars for empty leaderboard")
 }
 
+func TestFormatStars_UnlockedDays(t *testing.T) {
+	leaderboardData := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {
+				ID:   1,
+				Name: "Alice",
+				CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+					"1": {Level1: &aoc.StarDetail{GetStarTs: 1672444800}},
+				},
+				Stars: 1,
+			},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+
+	embed := formatterAt(time.Date(2024, 12, 3, 12, 0, 0, 0, time.UTC)).FormatStars(leaderboardData)
+
+	expectedDescription := "```Day  1  2  3\n" +
+		"     ☆        Alice```"
+	assert.Equal(t, expectedDescription, embed.Description, "Every unlocked day should have a column, solved or not")
+}
+
 func TestFormatStars_NilLeaderboard(t *testing.T) {
 	// Call the function with nil
 	embed := FormatStars(nil)
@@ -153,3 +178,27 @@ func TestFormatStars_NilLeaderboard(t *testing.T) {
 	// Assertions
 	assert.Nil(t, embed, "Embed should be nil for nil leaderboard")
 }
+
+func TestFormatBaseline(t *testing.T) {
+	leaderboardData := &aoc.Leaderboard{
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", Stars: 5},
+			"2": {ID: 2, Name: "Bob", Stars: 4},
+		},
+		Event:   "2024",
+		OwnerID: 12345,
+	}
+
+	summary := FormatBaseline(leaderboardData)
+
+	assert.Equal(t, "Tracking 2 members, 9 stars so far", summary, "Summary should count members and stars")
+	assert.Equal(t, "", FormatBaseline(nil), "Summary should be empty for nil leaderboard")
+}
+
+// formatterAt returns a formatter with the default messages that sees the
+// puzzles unlocked at now.
+func formatterAt(now time.Time) *Formatter {
+	f := NewFormatter(messages.Default(), time.UTC)
+	f.Now = func() time.Time { return now }
+	return f
+}
@@ -0,0 +1,205 @@
+package leaderboard
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/messages"
+	"github.com/bwmarrin/discordgo"
+)
+
+// PartDuel is one star of a day as two members got it. A zero time means the
+// member does not have the star.
+type PartDuel struct {
+	Part   int
+	Member time.Time
+	Rival  time.Time
+}
+
+// DayDuel is how two members did on one day. Difference is the member's
+// points minus the rival's over every day up to and including this one.
+type DayDuel struct {
+	Day          int
+	Parts        []PartDuel
+	MemberPoints int
+	RivalPoints  int
+	Difference   int
+}
+
+// Versus compares two members day by day. The record counts the stars each
+// got first, a star only one of them has counting for them.
+type Versus struct {
+	Member      string
+	Rival       string
+	Days        []DayDuel
+	MemberFirst int
+	RivalFirst  int
+	Ties        int
+	Difference  int
+}
+
+// Compare works out a head-to-head between member and rival from the star
+// times on the leaderboard. Points are local scores: a star is worth as many
+// points as there are members, minus one for everyone who got it earlier.
+func Compare(leaderboard *aoc.Leaderboard, member, rival aoc.Member) Versus {
+	versus := Versus{Member: member.Name, Rival: rival.Name}
+	if leaderboard == nil {
+		return versus
+	}
+	points := starPoints(leaderboard)
+
+	for day := 1; day <= calendar(leaderboard).Days; day++ {
+		key := strconv.Itoa(day)
+		memberLevel, rivalLevel := member.CompletionDayLevels[key], rival.CompletionDayLevels[key]
+
+		duel := DayDuel{Day: day}
+		for part, details := range [2][2]*aoc.StarDetail{
+			{memberLevel.Level1, rivalLevel.Level1},
+			{memberLevel.Level2, rivalLevel.Level2},
+		} {
+			if details[0] == nil && details[1] == nil {
+				continue
+			}
+			duel.Parts = append(duel.Parts, PartDuel{
+				Part:   part + 1,
+				Member: starTime(details[0]),
+				Rival:  starTime(details[1]),
+			})
+			duel.MemberPoints += points[member.ID][day][part]
+			duel.RivalPoints += points[rival.ID][day][part]
+
+			switch {
+			case details[1] == nil || (details[0] != nil && details[0].GetStarTs < details[1].GetStarTs):
+				versus.MemberFirst++
+			case details[0] == nil || details[1].GetStarTs < details[0].GetStarTs:
+				versus.RivalFirst++
+			default:
+				versus.Ties++
+			}
+		}
+		if len(duel.Parts) == 0 {
+			continue
+		}
+		versus.Difference += duel.MemberPoints - duel.RivalPoints
+		duel.Difference = versus.Difference
+		versus.Days = append(versus.Days, duel)
+	}
+	return versus
+}
+
+func starTime(detail *aoc.StarDetail) time.Time {
+	if detail == nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(detail.GetStarTs), 0)
+}
+
+// starPoints returns the local score points each member got for each star,
+// by member ID, day and part.
+func starPoints(leaderboard *aoc.Leaderboard) map[int]map[int][2]int {
+	type solve struct {
+		memberID int
+		detail   *aoc.StarDetail
+	}
+
+	points := make(map[int]map[int][2]int)
+	if leaderboard == nil {
+		return points
+	}
+	for day := 1; day <= calendar(leaderboard).Days; day++ {
+		key := strconv.Itoa(day)
+		for part := 0; part < 2; part++ {
+			var solves []solve
+			for _, member := range leaderboard.Members {
+				level := member.CompletionDayLevels[key]
+				detail := level.Level1
+				if part == 1 {
+					detail = level.Level2
+				}
+				if detail != nil {
+					solves = append(solves, solve{member.ID, detail})
+				}
+			}
+			sort.Slice(solves, func(i, j int) bool {
+				if solves[i].detail.GetStarTs != solves[j].detail.GetStarTs {
+					return solves[i].detail.GetStarTs < solves[j].detail.GetStarTs
+				}
+				return solves[i].detail.StarIndex < solves[j].detail.StarIndex
+			})
+			for i, s := range solves {
+				if points[s.memberID] == nil {
+					points[s.memberID] = make(map[int][2]int)
+				}
+				dayPoints := points[s.memberID][day]
+				dayPoints[part] = len(leaderboard.Members) - i
+				points[s.memberID][day] = dayPoints
+			}
+		}
+	}
+	return points
+}
+
+// FormatVersus formats a head-to-head with the default messages.
+func FormatVersus(versus Versus) *discordgo.MessageEmbed {
+	return defaultFormatter.FormatVersus(versus)
+}
+
+// FormatVersus formats a head-to-head: a line for each day with who got each
+// star first and by how much, the points of the day and the running point
+// difference, followed by the overall record.
+func (f *Formatter) FormatVersus(versus Versus) *discordgo.MessageEmbed {
+	data := messages.Data{Member: versus.Member, Rival: versus.Rival}
+	embed := &discordgo.MessageEmbed{
+		Title: f.Messages.Render(messages.VsTitle, data),
+		Color: f.Messages.Color(messages.LeaderboardColor),
+	}
+	if len(versus.Days) == 0 {
+		embed.Description = f.Messages.Render(messages.VsNone, data)
+		return embed
+	}
+
+	var lines []string
+	for _, day := range versus.Days {
+		var parts []string
+		for _, part := range day.Parts {
+			parts = append(parts, f.formatPartDuel(versus, part))
+		}
+		lines = append(lines, f.Messages.Render(messages.VsDay, messages.Data{
+			Member: versus.Member,
+			Rival:  versus.Rival,
+			Day:    day.Day,
+			Lines:  parts,
+			From:   day.MemberPoints,
+			To:     day.RivalPoints,
+			Delta:  day.Difference,
+		}))
+	}
+	lines = append(lines, "", f.Messages.Render(messages.VsRecord, messages.Data{
+		Member: versus.Member,
+		Rival:  versus.Rival,
+		From:   versus.MemberFirst,
+		To:     versus.RivalFirst,
+		Count:  versus.Ties,
+		Delta:  versus.Difference,
+	}))
+	embed.Description = strings.Join(lines, "\n")
+	return embed
+}
+
+func (f *Formatter) formatPartDuel(versus Versus, part PartDuel) string {
+	switch {
+	case part.Rival.IsZero():
+		return f.Messages.Render(messages.VsPartOnly, messages.Data{Part: part.Part, Member: versus.Member})
+	case part.Member.IsZero():
+		return f.Messages.Render(messages.VsPartOnly, messages.Data{Part: part.Part, Member: versus.Rival})
+	case part.Member.Before(part.Rival):
+		return f.Messages.Render(messages.VsPart, messages.Data{Part: part.Part, Member: versus.Member, Elapsed: part.Rival.Sub(part.Member)})
+	case part.Rival.Before(part.Member):
+		return f.Messages.Render(messages.VsPart, messages.Data{Part: part.Part, Member: versus.Rival, Elapsed: part.Member.Sub(part.Rival)})
+	default:
+		return f.Messages.Render(messages.VsPartTie, messages.Data{Part: part.Part})
+	}
+}
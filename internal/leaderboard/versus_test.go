@@ -0,0 +1,120 @@
+// internal/leaderboard/versus_test.go
+
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func versusLeaderboard() *aoc.Leaderboard {
+	day1 := int(aoc.UnlockTime(2024, 1).Unix())
+	day2 := int(aoc.UnlockTime(2024, 2).Unix())
+	return &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 100), Level2: star(day1 + 400)},
+				"2": {Level1: star(day2 + 900)},
+			}},
+			"2": {ID: 2, Name: "Bob", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 160), Level2: star(day1 + 300)},
+				"2": {Level1: star(day2 + 600), Level2: star(day2 + 1200)},
+			}},
+			"3": {ID: 3, Name: "Charlie", CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"1": {Level1: star(day1 + 50)},
+			}},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	lb := versusLeaderboard()
+
+	versus := Compare(lb, lb.Members["1"], lb.Members["2"])
+
+	assert.Equal(t, "Alice", versus.Member, "Member should match")
+	assert.Equal(t, "Bob", versus.Rival, "Rival should match")
+	if assert.Len(t, versus.Days, 2, "Should have a day for each day either has a star") {
+		day1 := versus.Days[0]
+		assert.Equal(t, 1, day1.Day, "Days should be in order")
+		assert.Len(t, day1.Parts, 2, "Both parts should be compared")
+		assert.Equal(t, 2+2, day1.MemberPoints, "Alice was second of three on part 1 and second of two on part 2")
+		assert.Equal(t, 1+3, day1.RivalPoints, "Bob was third on part 1 and first on part 2")
+		assert.Equal(t, 0, day1.Difference, "Points should be even after day 1")
+
+		day2 := versus.Days[1]
+		assert.Len(t, day2.Parts, 2, "A part only one of them has should be included")
+		assert.True(t, day2.Parts[1].Member.IsZero(), "Alice has no second star on day 2")
+		assert.Equal(t, 2, day2.MemberPoints, "Alice was second on part 1")
+		assert.Equal(t, 3+3, day2.RivalPoints, "Bob was first on both parts")
+		assert.Equal(t, -4, day2.Difference, "The difference should add up over the days")
+	}
+	assert.Equal(t, 1, versus.MemberFirst, "Alice got one star first")
+	assert.Equal(t, 3, versus.RivalFirst, "Bob got three stars first, including the one only he has")
+	assert.Equal(t, 0, versus.Ties, "Nobody should tie")
+	assert.Equal(t, -4, versus.Difference, "The overall difference should match the last day")
+}
+
+func TestCompare_NoStars(t *testing.T) {
+	lb := &aoc.Leaderboard{Members: map[string]aoc.Member{
+		"1": {ID: 1, Name: "Alice"},
+		"2": {ID: 2, Name: "Bob"},
+	}}
+
+	versus := Compare(lb, lb.Members["1"], lb.Members["2"])
+
+	assert.Empty(t, versus.Days, "Should have no days without stars")
+	assert.Equal(t, "Neither Alice nor Bob has a star yet", FormatVersus(versus).Description, "Should say there is nothing to compare")
+}
+
+func TestCompare_OnlyEventDays(t *testing.T) {
+	star := &aoc.StarDetail{GetStarTs: 1}
+	levels := map[string]aoc.CompletionDayLevel{
+		"12": {Level1: star},
+		"13": {Level1: star},
+	}
+	lb := &aoc.Leaderboard{Event: "2025", Members: map[string]aoc.Member{
+		"1": {ID: 1, Name: "Alice", CompletionDayLevels: levels},
+		"2": {ID: 2, Name: "Bob"},
+	}}
+
+	versus := Compare(lb, lb.Members["1"], lb.Members["2"])
+
+	if assert.Len(t, versus.Days, 1, "Only the days of the 2025 event should be compared") {
+		assert.Equal(t, 12, versus.Days[0].Day, "The last day of the 2025 event should be compared")
+	}
+}
+
+func TestFormatVersus(t *testing.T) {
+	versus := Versus{
+		Member: "Alice",
+		Rival:  "Bob",
+		Days: []DayDuel{
+			{Day: 1, MemberPoints: 4, RivalPoints: 4, Parts: []PartDuel{
+				{Part: 1, Member: time.Unix(100, 0), Rival: time.Unix(160, 0)},
+				{Part: 2, Member: time.Unix(400, 0), Rival: time.Unix(300, 0)},
+			}},
+			{Day: 2, MemberPoints: 2, RivalPoints: 6, Difference: -4, Parts: []PartDuel{
+				{Part: 1, Member: time.Unix(900, 0), Rival: time.Unix(900, 0)},
+				{Part: 2, Rival: time.Unix(1200, 0)},
+			}},
+		},
+		MemberFirst: 1,
+		RivalFirst:  2,
+		Ties:        1,
+		Difference:  -4,
+	}
+
+	embed := FormatVersus(versus)
+
+	assert.Equal(t, "Alice vs Bob", embed.Title, "Embed title should match")
+	expected := "Day 1: part 1 Alice by 1m 00s, part 2 Bob by 1m 40s | 4–4 points, +0 overall\n" +
+		"Day 2: part 1 tied, part 2 only Bob | 2–6 points, -4 overall\n" +
+		"\n" +
+		"Record: Alice 1–2 Bob on first stars (1 tied), -4 points for Alice"
+	assert.Equal(t, expected, embed.Description, "Description should have a line per day and the record")
+}
@@ -0,0 +1,104 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long shutdown may take when no timeout is given.
+const DefaultTimeout = 10 * time.Second
+
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager runs the long-lived parts of the bot and shuts them down together.
+// Components run until the context passed to Run is cancelled or one of them
+// fails. Shutdown hooks then run in the order they were added, and the whole
+// shutdown has to finish within the timeout.
+type Manager struct {
+	timeout    time.Duration
+	components []component
+	hooks      []hook
+}
+
+func NewManager(timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Manager{timeout: timeout}
+}
+
+// Add registers a component. run must block until ctx is cancelled and then
+// return promptly.
+func (m *Manager) Add(name string, run func(ctx context.Context) error) {
+	m.components = append(m.components, component{name: name, run: run})
+}
+
+// OnShutdown registers a hook that runs once every component has stopped.
+// Its ctx expires at the shutdown deadline.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Run starts every component and blocks until shutdown has finished.
+func (m *Manager) Run(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	failures := make(chan error, len(m.components))
+	for _, c := range m.components {
+		wg.Add(1)
+		go func(c component) {
+			defer wg.Done()
+			if err := c.run(runCtx); err != nil && !errors.Is(err, context.Canceled) {
+				failures <- fmt.Errorf("%s: %w", c.name, err)
+			}
+		}(c)
+	}
+
+	var errs []error
+	select {
+	case <-ctx.Done():
+	case err := <-failures:
+		log.Printf("component failed: %v", err)
+		errs = append(errs, err)
+	}
+
+	log.Printf("Shutting down...")
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), m.timeout)
+	defer cancelShutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		errs = append(errs, fmt.Errorf("components did not stop within %s", m.timeout))
+	}
+
+	for _, h := range m.hooks {
+		if err := h.fn(shutdownCtx); err != nil {
+			log.Printf("error running shutdown hook %s: %v", h.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
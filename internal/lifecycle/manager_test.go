@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForCancel(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestManagerStopsComponentsOnCancel(t *testing.T) {
+	m := NewManager(time.Second)
+
+	stopped := make(chan string, 2)
+	for _, name := range []string{"first", "second"} {
+		name := name
+		m.Add(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			stopped <- name
+			return nil
+		})
+	}
+
+	var order []string
+	m.OnShutdown("hook one", func(ctx context.Context) error {
+		assert.Len(t, stopped, 2, "Hooks should run after every component has stopped")
+		order = append(order, "hook one")
+		return nil
+	})
+	m.OnShutdown("hook two", func(ctx context.Context) error {
+		order = append(order, "hook two")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.Run(ctx)
+
+	assert.NoError(t, err, "Run should succeed when everything stops cleanly")
+	assert.Equal(t, []string{"hook one", "hook two"}, order, "Hooks should run in the order they were added")
+}
+
+func TestManagerComponentFailureTriggersShutdown(t *testing.T) {
+	m := NewManager(time.Second)
+
+	m.Add("failing", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	m.Add("healthy", waitForCancel)
+
+	hookRan := false
+	m.OnShutdown("hook", func(ctx context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	err := m.Run(context.Background())
+
+	assert.Error(t, err, "Run should report the component failure")
+	assert.Contains(t, err.Error(), "failing: boom", "Error should name the failed component")
+	assert.True(t, hookRan, "Shutdown hooks should still run")
+}
+
+func TestManagerShutdownDeadline(t *testing.T) {
+	m := NewManager(50 * time.Millisecond)
+
+	m.Add("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	var hookErr error
+	m.OnShutdown("hook", func(ctx context.Context) error {
+		<-ctx.Done()
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := m.Run(ctx)
+
+	assert.Error(t, err, "Run should report components that did not stop in time")
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Shutdown should not wait past the deadline")
+	assert.ErrorIs(t, hookErr, context.DeadlineExceeded, "Hooks should get a context bounded by the deadline")
+}
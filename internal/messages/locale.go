@@ -0,0 +1,196 @@
+package messages
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultLocale is the locale used when none is configured. Every other
+// locale must define the same messages.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Locale is a message catalog for one language, together with how numbers,
+// dates and durations are written in it.
+type Locale struct {
+	Code               string            `json:"-"`
+	Name               string            `json:"name"`
+	ThousandsSeparator string            `json:"thousands_separator"`
+	DateLayout         string            `json:"date_layout"`
+	Months             []string          `json:"months"`
+	DurationUnits      []string          `json:"duration_units"`
+	Messages           map[string]string `json:"messages"`
+}
+
+var locales = mustLoadLocales()
+
+func mustLoadLocales() map[string]*Locale {
+	loaded, err := loadLocales()
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+// loadLocales reads the embedded locale files, named after their locale code,
+// and checks that each one is complete.
+func loadLocales() (map[string]*Locale, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, fmt.Errorf("error reading locales: %w", err)
+	}
+
+	loaded := make(map[string]*Locale, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading locale %s: %w", entry.Name(), err)
+		}
+		var loc Locale
+		if err := json.Unmarshal(data, &loc); err != nil {
+			return nil, fmt.Errorf("error unmarshalling locale %s: %w", entry.Name(), err)
+		}
+		loc.Code = strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		loaded[loc.Code] = &loc
+	}
+
+	reference, ok := loaded[DefaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("default locale %q is missing", DefaultLocale)
+	}
+	for _, loc := range loaded {
+		if err := loc.check(reference); err != nil {
+			return nil, err
+		}
+	}
+	return loaded, nil
+}
+
+// check reports a locale that does not define exactly the messages of the
+// reference locale, or whose formatting settings are incomplete.
+func (l *Locale) check(reference *Locale) error {
+	for name := range reference.Messages {
+		if _, ok := l.Messages[name]; !ok {
+			return fmt.Errorf("locale %q is missing message %q", l.Code, name)
+		}
+	}
+	for name := range l.Messages {
+		if _, ok := reference.Messages[name]; !ok {
+			return fmt.Errorf("locale %q defines unknown message %q", l.Code, name)
+		}
+	}
+	if len(l.Months) != 12 {
+		return fmt.Errorf("locale %q must name 12 months, got %d", l.Code, len(l.Months))
+	}
+	if len(l.DurationUnits) != 4 {
+		return fmt.Errorf("locale %q must name 4 duration units (days, hours, minutes, seconds), got %d", l.Code, len(l.DurationUnits))
+	}
+	return nil
+}
+
+// Locales returns the available locale codes in alphabetical order.
+func Locales() []string {
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Names returns the names of all templates in alphabetical order.
+func Names() []string {
+	reference := locales[DefaultLocale]
+	names := make([]string, 0, len(reference.Messages)+len(colorDefaults))
+	for name := range reference.Messages {
+		names = append(names, name)
+	}
+	for name := range colorDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// funcs are the functions templates can call: join, number, duration, date
+// and timestamp.
+func (l *Locale) funcs() template.FuncMap {
+	return template.FuncMap{
+		"join":      strings.Join,
+		"number":    l.FormatNumber,
+		"duration":  l.FormatDuration,
+		"date":      l.FormatDate,
+		"timestamp": Timestamp,
+	}
+}
+
+// Timestamp writes t as Discord timestamp markup, which every reader sees in
+// their own timezone and language. style is one of Discord's formats, such as
+// "R" for relative ("in 2 hours") or "t" for a short time.
+func Timestamp(t time.Time, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
+
+// FormatNumber writes n with the locale's thousands separator.
+func (l *Locale) FormatNumber(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var sb strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(l.ThousandsSeparator)
+		}
+		sb.WriteRune(digit)
+	}
+	return sign + sb.String()
+}
+
+// FormatDuration writes d to the second, e.g. "1h 02m 03s", leaving out
+// leading units that are zero.
+func (l *Locale) FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	d = d.Round(time.Second)
+	values := []int{
+		int(d / (24 * time.Hour)),
+		int(d % (24 * time.Hour) / time.Hour),
+		int(d % time.Hour / time.Minute),
+		int(d % time.Minute / time.Second),
+	}
+
+	var parts []string
+	for i, value := range values {
+		if len(parts) == 0 && value == 0 && i < len(values)-1 {
+			continue
+		}
+		if len(parts) == 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", value, l.DurationUnits[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%02d%s", value, l.DurationUnits[i]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatDate writes t with the locale's date layout and month names. t is
+// written in its own location.
+func (l *Locale) FormatDate(t time.Time) string {
+	// time.Format only knows English month names, so the month is put in
+	// afterwards.
+	layout := strings.ReplaceAll(l.DateLayout, "Jan", "\x00")
+	return strings.ReplaceAll(t.Format(layout), "\x00", l.Months[t.Month()-1])
+}
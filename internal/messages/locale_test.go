@@ -0,0 +1,87 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalesDefineEveryMessage(t *testing.T) {
+	reference := locales[DefaultLocale]
+
+	for _, code := range Locales() {
+		t.Run(code, func(t *testing.T) {
+			loc := locales[code]
+			for name := range reference.Messages {
+				assert.Contains(t, loc.Messages, name, "Locale should define every message")
+			}
+			assert.Len(t, loc.Messages, len(reference.Messages), "Locale should not define unknown messages")
+
+			_, err := New(code, nil)
+			assert.NoError(t, err, "Every message should render")
+		})
+	}
+}
+
+func TestLocaleCheck(t *testing.T) {
+	reference := locales[DefaultLocale]
+	incomplete := &Locale{
+		Code:          "xx",
+		Months:        reference.Months,
+		DurationUnits: reference.DurationUnits,
+		Messages:      map[string]string{NewStar: "{{.Member}}"},
+	}
+
+	err := incomplete.check(reference)
+
+	assert.Error(t, err, "A locale missing messages should be rejected")
+}
+
+func TestNewUnknownLocale(t *testing.T) {
+	_, err := New("tlh", nil)
+
+	assert.Error(t, err, "An unknown locale should be rejected")
+	assert.Contains(t, err.Error(), "en", "Error should list the available locales")
+}
+
+func TestFrenchMessages(t *testing.T) {
+	msgs, err := New("fr", nil)
+
+	assert.NoError(t, err, "French should be available")
+	assert.Equal(t, "Alice a gagné une étoile ! 🌟", msgs.Render(NewStar, Data{Member: "Alice"}), "NewStar should be translated")
+	assert.Equal(t, "1. Alice - 1 234 points (5 étoiles)", msgs.Render(LeaderboardLine, Data{Rank: 1, Member: "Alice", Score: 1234, Stars: 5}), "Numbers should use the French separator")
+}
+
+func TestFormatNumber(t *testing.T) {
+	en := locales["en"]
+
+	assert.Equal(t, "0", en.FormatNumber(0), "Zero should match")
+	assert.Equal(t, "999", en.FormatNumber(999), "Small numbers should have no separator")
+	assert.Equal(t, "1,000", en.FormatNumber(1000), "Thousands should be separated")
+	assert.Equal(t, "-1,234,567", en.FormatNumber(-1234567), "Negative numbers should be separated")
+	assert.Equal(t, "1 234", locales["fr"].FormatNumber(1234), "French should use a narrow space")
+}
+
+func TestFormatDuration(t *testing.T) {
+	en := locales["en"]
+
+	assert.Equal(t, "42s", en.FormatDuration(42*time.Second), "Seconds should match")
+	assert.Equal(t, "12m 05s", en.FormatDuration(12*time.Minute+5*time.Second), "Minutes should match")
+	assert.Equal(t, "1h 02m 03s", en.FormatDuration(time.Hour+2*time.Minute+3*time.Second), "Hours should match")
+	assert.Equal(t, "2d 00h 00m 00s", en.FormatDuration(48*time.Hour), "Days should match")
+	assert.Equal(t, "1 h 02 min 03 s", locales["fr"].FormatDuration(time.Hour+2*time.Minute+3*time.Second), "French units should be used")
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, 12, 1, 5, 12, 0, 0, time.UTC)
+
+	assert.Equal(t, "Dec 1, 05:12 UTC", locales["en"].FormatDate(date), "English date should match")
+	assert.Equal(t, "1 déc., 05:12 UTC", locales["fr"].FormatDate(date), "French date should use French month names")
+}
+
+func TestTimestamp(t *testing.T) {
+	unlock := time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "<t:1733029200:R>", Timestamp(unlock, "R"), "Timestamp should use Discord markup")
+}
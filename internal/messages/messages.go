@@ -0,0 +1,417 @@
+// Package messages renders the text the bot posts to Discord from
+// text/template templates. The default text of every message comes from a
+// locale file, and can be overridden by name in the configuration.
+package messages
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template names. The comment on each lists the Data fields it can use.
+const (
+	// Baseline announces a newly tracked leaderboard. Count, Stars.
+	Baseline = "baseline"
+	// UpdatesTitle is the title of the update embed.
+	UpdatesTitle = "updates_title"
+	// DayHeading heads the stars earned on one day. Day, Title (of the
+	// puzzle, empty if it is not known).
+	DayHeading = "day_heading"
+	// PartLine lists the members who earned one part of a day. Day, Part,
+	// Members.
+	PartLine = "part_line"
+	// StarEntry is a member in a PartLine. Member, Time, Elapsed (since the
+	// puzzle unlocked).
+	StarEntry = "star_entry"
+	// NewStarsHeading heads the stars of members when the leaderboard does
+	// not say which day they were earned on.
+	NewStarsHeading = "new_stars_heading"
+	// NewStar is one of those members. Member.
+	NewStar = "new_star"
+	// RankChangesHeading heads the rank changes.
+	RankChangesHeading = "rank_changes_heading"
+	// RankUp is a member moving up. Member, From, To, Delta.
+	RankUp = "rank_up"
+	// RankDown is a member moving down. Member, From, To, Delta.
+	RankDown = "rank_down"
+	// NewMembersHeading heads the members who joined.
+	NewMembersHeading = "new_members_heading"
+	// NewMember is a member who joined. Member.
+	NewMember = "new_member"
+	// LeaderboardTitle is the title of the leaderboard embed.
+	LeaderboardTitle = "leaderboard_title"
+	// LeaderboardLine is one member on the leaderboard. Rank, Member, Score,
+	// Stars.
+	LeaderboardLine = "leaderboard_line"
+	// AllTimeTitle is the title of the all-time standings embed.
+	AllTimeTitle = "all_time_title"
+	// AllTimeLine is one member in the all-time standings. Rank, Member,
+	// Score, Stars, Count (of events they took part in).
+	AllTimeLine = "all_time_line"
+	// AllTimeUsage answers !alltime with an unknown ordering.
+	AllTimeUsage = "all_time_usage"
+	// YearUnknown answers a command for a year that has no archived
+	// leaderboard. Year.
+	YearUnknown = "year_unknown"
+	// AdminOnly answers !admin from someone who is not an admin.
+	AdminOnly = "admin_only"
+	// AdminUsage answers !admin with unknown arguments.
+	AdminUsage = "admin_usage"
+	// AdminPaused confirms that notifications are paused.
+	AdminPaused = "admin_paused"
+	// AdminResumed confirms that notifications are back on.
+	AdminResumed = "admin_resumed"
+	// AdminChannelSet confirms a new announcement channel. Channel.
+	AdminChannelSet = "admin_channel_set"
+	// AdminAliasSet confirms a member's alias. Member, Alias.
+	AdminAliasSet = "admin_alias_set"
+	// AdminAliasCleared confirms that a member's alias was removed. Member.
+	AdminAliasCleared = "admin_alias_cleared"
+	// AdminLinked confirms that an admin linked a user to a member. Mention
+	// (of the user), Member.
+	AdminLinked = "admin_linked"
+	// AdminUnlinked confirms that an admin removed a user's link. Mention
+	// (of the user).
+	AdminUnlinked = "admin_unlinked"
+	// AdminStatus shows the bot's status. Lines.
+	AdminStatus = "admin_status"
+	// AdminStatusFetch is the status of the leaderboard. Time (the last
+	// successful fetch, zero if there was none), Count (members).
+	AdminStatusFetch = "admin_status_fetch"
+	// AdminStatusChannel is the announcement channel. Channel.
+	AdminStatusChannel = "admin_status_channel"
+	// AdminStatusPaused says that notifications are paused.
+	AdminStatusPaused = "admin_status_paused"
+	// AdminStatusError is a recent error. Time, Description.
+	AdminStatusError = "admin_status_error"
+	// AdminStatusNoErrors says there were no recent errors.
+	AdminStatusNoErrors = "admin_status_no_errors"
+	// RemindUsage answers !remind with unknown arguments.
+	RemindUsage = "remind_usage"
+	// RemindAtSet confirms a daily reminder. Clock, Zone.
+	RemindAtSet = "remind_at_set"
+	// RemindBeforeSet confirms a reminder before each unlock. Count (the
+	// minutes before).
+	RemindBeforeSet = "remind_before_set"
+	// RemindPassedSet confirms notices about being passed. Member.
+	RemindPassedSet = "remind_passed_set"
+	// RemindList lists a user's reminders. Lines (the confirmations of each).
+	RemindList = "remind_list"
+	// RemindNone answers !remind for a user without reminders.
+	RemindNone = "remind_none"
+	// RemindOff confirms that reminders were cancelled.
+	RemindOff = "remind_off"
+	// RemindNeedsLink answers a reminder that needs a linked member.
+	RemindNeedsLink = "remind_needs_link"
+	// RemindAtMessage is the DM sent at a user's chosen time. Day, Title,
+	// Stars (the stars they have of the day).
+	RemindAtMessage = "remind_at_message"
+	// RemindBeforeMessage is the DM sent before an unlock. Day, Time (the
+	// unlock).
+	RemindBeforeMessage = "remind_before_message"
+	// RemindPassedMessage is the DM sent when someone passes a user's
+	// member. Member, Members (who passed them).
+	RemindPassedMessage = "remind_passed_message"
+	// StreakMilestone announces a member reaching a streak milestone.
+	// Member, Count (the length of the streak in days).
+	StreakMilestone = "streak_milestone"
+	// StreakNudge reminds a linked member that their streak is about to
+	// break. Mention, Count (the length of the streak), Day (the day still
+	// to complete), Time (when the streak breaks).
+	StreakNudge = "streak_nudge"
+	// VsTitle is the title of a !vs comparison. Member, Rival.
+	VsTitle = "vs_title"
+	// VsDay is one day of a !vs comparison. Member, Rival, Day, Lines (the
+	// rendered parts), From and To (the points each got that day), Delta
+	// (Member's points minus Rival's so far).
+	VsDay = "vs_day"
+	// VsPart is a star both got. Part, Member (who got it first), Elapsed
+	// (how much earlier).
+	VsPart = "vs_part"
+	// VsPartOnly is a star only one of them has. Part, Member.
+	VsPartOnly = "vs_part_only"
+	// VsPartTie is a star both got in the same second. Part.
+	VsPartTie = "vs_part_tie"
+	// VsRecord sums up a !vs comparison. Member, Rival, From and To (the
+	// stars each got first), Count (ties), Delta (Member's points minus
+	// Rival's).
+	VsRecord = "vs_record"
+	// VsNone answers !vs for members without any stars. Member, Rival.
+	VsNone = "vs_none"
+	// VsUsage answers !vs without two members.
+	VsUsage = "vs_usage"
+	// StarsTitle is the title of the star grid embed.
+	StarsTitle = "stars_title"
+	// StarsHeader heads the day numbers of the star grid.
+	StarsHeader = "stars_header"
+	// StarsWeek names a week of the star grid, above its days. Count (the
+	// week number). It should be short enough to fit over a compact week.
+	StarsWeek = "stars_week"
+	// StarsUsage answers !stars with arguments it does not understand.
+	StarsUsage = "stars_usage"
+	// StarsNoDays answers !stars with a range of days none of which has
+	// unlocked. From, To (the range), Count (the days unlocked).
+	StarsNoDays = "stars_no_days"
+	// NoUpdates answers !update when nothing changed.
+	NoUpdates = "no_updates"
+	// UpdateFailed answers !update when the leaderboard could not be
+	// fetched.
+	UpdateFailed = "update_failed"
+	// UpdateCooldown answers !update when it was used too recently. Elapsed
+	// (the time left until it can be used again).
+	UpdateCooldown = "update_cooldown"
+	// CommandCooldown answers a command used more often than its limit
+	// allows. Command, Elapsed (the time left until it can be used again).
+	CommandCooldown = "command_cooldown"
+	// CommandInProgress answers a command sent again while the same request
+	// is still being answered. Command.
+	CommandInProgress = "command_in_progress"
+	// TimesHeading heads the !times output. Day, Title, Time (of the unlock).
+	TimesHeading = "times_heading"
+	// TimesLine is one star in the !times output. Member, Part, Time,
+	// Elapsed (since the puzzle unlocked).
+	TimesLine = "times_line"
+	// TimesNone answers !times for a day nobody has solved. Day.
+	TimesNone = "times_none"
+	// TimesMore ends a !times output that is too long for one message.
+	// Count (the stars left out).
+	TimesMore = "times_more"
+	// TimesUsage answers !times with an invalid day.
+	TimesUsage = "times_usage"
+	// LinkUsage answers !link without a member.
+	LinkUsage = "link_usage"
+	// LinkNotFound answers !link with an unknown member. Member.
+	LinkNotFound = "link_not_found"
+	// LinkTaken answers !link with a member linked to another user. Member.
+	LinkTaken = "link_taken"
+	// Linked confirms !link. Member.
+	Linked = "linked"
+	// Unlinked confirms !unlink.
+	Unlinked = "unlinked"
+	// TimezoneUsage answers !timezone without a zone. Zone (the current one).
+	TimezoneUsage = "timezone_usage"
+	// TimezoneInvalid answers !timezone with an unknown zone. Zone.
+	TimezoneInvalid = "timezone_invalid"
+	// TimezoneSet confirms !timezone. Zone.
+	TimezoneSet = "timezone_set"
+	// ProfileError reports that a user's settings could not be saved.
+	ProfileError = "profile_error"
+	// HelpHeading heads the !help output.
+	HelpHeading = "help_heading"
+	// HelpLine is one command in the !help output. Command, Description.
+	HelpLine = "help_line"
+	// The Command messages describe the commands in the !help output.
+	CommandLeaderboard = "command_leaderboard"
+	CommandUpdate      = "command_update"
+	CommandStars       = "command_stars"
+	CommandHelp        = "command_help"
+	CommandTimes       = "command_times"
+	CommandLink        = "command_link"
+	CommandUnlink      = "command_unlink"
+	CommandTimezone    = "command_timezone"
+	CommandAllTime     = "command_all_time"
+	CommandVs          = "command_vs"
+	CommandRemind      = "command_remind"
+	CommandAdmin       = "command_admin"
+	// ThreadName is the name of a puzzle day's discussion thread. Day, Title.
+	ThreadName = "thread_name"
+	// ConfigReloaded announces a configuration reload. Lines.
+	ConfigReloaded = "config_reloaded"
+
+	// LeaderboardColor, StarsColor and UpdatesColor render the embed colors,
+	// e.g. "0x034F20".
+	LeaderboardColor = "leaderboard_color"
+	StarsColor       = "stars_color"
+	UpdatesColor     = "updates_color"
+)
+
+// colorDefaults are the embed colors. They are the same in every locale.
+var colorDefaults = map[string]string{
+	LeaderboardColor: "0x034F20",
+	StarsColor:       "0xB22222",
+	UpdatesColor:     "0x034F20",
+}
+
+// Data holds the values a template can refer to. Which fields are set
+// depends on the message, see the template names.
+type Data struct {
+	Title       string
+	Year        int
+	Member      string
+	Rival       string
+	Mention     string
+	Members     []string
+	Day         int
+	Part        int
+	Rank        int
+	From        int
+	To          int
+	Delta       int
+	Score       int
+	Stars       int
+	Count       int
+	Time        time.Time
+	Elapsed     time.Duration
+	Zone        string
+	Clock       string
+	Channel     string
+	Alias       string
+	Command     string
+	Description string
+	Lines       []string
+}
+
+// sample is used to check that templates render at startup.
+var sample = Data{
+	Title:       "Camel Cards",
+	Year:        2023,
+	Member:      "Alice",
+	Rival:       "Bob",
+	Mention:     "<@123456789>",
+	Members:     []string{"Alice", "Bob"},
+	Day:         1,
+	Part:        2,
+	Rank:        1,
+	From:        3,
+	To:          1,
+	Delta:       2,
+	Score:       1234,
+	Stars:       10,
+	Count:       2,
+	Time:        time.Date(2024, 12, 1, 5, 12, 0, 0, time.UTC),
+	Elapsed:     12 * time.Minute,
+	Clock:       "20:00",
+	Channel:     "123456789",
+	Alias:       "Eve",
+	Zone:        "Europe/Paris",
+	Command:     "!help",
+	Description: "Shows this message",
+	Lines:       []string{"POLL_INTERVAL: 15m0s -> 30m0s"},
+}
+
+// Set is a parsed set of message templates for one locale.
+type Set struct {
+	locale    *Locale
+	templates map[string]*template.Template
+}
+
+// New parses the templates of the named locale with overrides applied on
+// top. Every template is rendered once with sample data, so that mistakes
+// such as an unknown field or an invalid color are reported here rather than
+// when the message is first sent. An empty locale means DefaultLocale.
+func New(locale string, overrides map[string]string) (*Set, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	loc, ok := locales[locale]
+	if !ok {
+		return nil, fmt.Errorf("unknown locale %q, available: %s", locale, strings.Join(Locales(), ", "))
+	}
+
+	set := &Set{locale: loc, templates: make(map[string]*template.Template)}
+	texts := make(map[string]string, len(loc.Messages)+len(colorDefaults))
+	for name, text := range loc.Messages {
+		texts[name] = text
+	}
+	for name, text := range colorDefaults {
+		texts[name] = text
+	}
+
+	for name := range overrides {
+		if _, ok := texts[name]; !ok {
+			return nil, fmt.Errorf("unknown message template %q", name)
+		}
+	}
+
+	for _, name := range Names() {
+		text := texts[name]
+		if override, ok := overrides[name]; ok {
+			text = override
+		}
+		tmpl, err := template.New(name).Funcs(loc.funcs()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing message template %q: %w", name, err)
+		}
+		set.templates[name] = tmpl
+
+		rendered, err := set.render(name, sample)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering message template %q: %w", name, err)
+		}
+		if _, ok := colorDefaults[name]; ok {
+			if _, err := parseColor(rendered); err != nil {
+				return nil, fmt.Errorf("message template %q: %w", name, err)
+			}
+		}
+	}
+
+	return set, nil
+}
+
+var defaultSet *Set
+
+func init() {
+	var err error
+	defaultSet, err = New(DefaultLocale, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Default returns the built-in templates of the default locale.
+func Default() *Set {
+	return defaultSet
+}
+
+// Locale returns the locale the set was created for.
+func (s *Set) Locale() *Locale {
+	return s.locale
+}
+
+// Render renders the named template. Templates are checked when the set is
+// created, so a failure here is only logged and the default text is used.
+func (s *Set) Render(name string, data Data) string {
+	rendered, err := s.render(name, data)
+	if err != nil {
+		log.Printf("error rendering message template %q: %v", name, err)
+		if s != defaultSet {
+			return defaultSet.Render(name, data)
+		}
+	}
+	return rendered
+}
+
+// Color renders the named color template.
+func (s *Set) Color(name string) int {
+	color, err := parseColor(s.Render(name, Data{}))
+	if err != nil {
+		log.Printf("error rendering color %q: %v", name, err)
+		return 0
+	}
+	return color
+}
+
+func (s *Set) render(name string, data Data) (string, error) {
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown message template %q", name)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseColor(s string) (int, error) {
+	color, err := strconv.ParseInt(strings.TrimSpace(s), 0, 32)
+	if err != nil || color < 0 || color > 0xFFFFFF {
+		return 0, fmt.Errorf("invalid color %q, expected a value such as 0x034F20", s)
+	}
+	return int(color), nil
+}
@@ -0,0 +1,70 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaults(t *testing.T) {
+	msgs := Default()
+
+	assert.Equal(t, "Alice got a star! 🌟", msgs.Render(NewStar, Data{Member: "Alice"}), "NewStar should match")
+	assert.Equal(t, "Part 2: Alice, Bob", msgs.Render(PartLine, Data{Part: 2, Members: []string{"Alice", "Bob"}}), "PartLine should join members")
+	assert.Equal(t, "1. Alice - 300 points (5 stars)", msgs.Render(LeaderboardLine, Data{Rank: 1, Member: "Alice", Score: 300, Stars: 5}), "LeaderboardLine should match")
+	assert.Equal(t, "Configuration reloaded:\n- A\n- B", msgs.Render(ConfigReloaded, Data{Lines: []string{"A", "B"}}), "ConfigReloaded should list the lines")
+	assert.Equal(t, 0x034F20, msgs.Color(LeaderboardColor), "LeaderboardColor should match")
+	assert.Equal(t, 0xB22222, msgs.Color(StarsColor), "StarsColor should match")
+}
+
+func TestOverrides(t *testing.T) {
+	msgs, err := New(DefaultLocale, map[string]string{
+		NewStar:          "⭐ {{.Member}} solved another one",
+		LeaderboardColor: "0xFFD700",
+	})
+
+	assert.NoError(t, err, "Valid overrides should be accepted")
+	assert.Equal(t, "⭐ Alice solved another one", msgs.Render(NewStar, Data{Member: "Alice"}), "Override should be used")
+	assert.Equal(t, 0xFFD700, msgs.Color(LeaderboardColor), "Color override should be used")
+	assert.Equal(t, "CHALLENGER APPROACHING!", msgs.Render(NewMembersHeading, Data{}), "Other messages should keep their defaults")
+}
+
+func TestNewRejectsInvalidTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+	}{
+		{"Unknown Template", map[string]string{"no_such_message": "hello"}},
+		{"Syntax Error", map[string]string{NewStar: "{{.Member"}},
+		{"Unknown Field", map[string]string{NewStar: "{{.Nickname}} got a star"}},
+		{"Invalid Color", map[string]string{StarsColor: "red"}},
+		{"Color Out Of Range", map[string]string{StarsColor: "0x1000000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(DefaultLocale, tt.overrides)
+
+			assert.Error(t, err, "Invalid overrides should be rejected")
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+
+	assert.Len(t, names, len(locales[DefaultLocale].Messages)+len(colorDefaults), "Every template should be listed")
+	for _, name := range []string{
+		Baseline, UpdatesTitle, DayHeading, PartLine, NewStarsHeading, NewStar,
+		RankChangesHeading, RankUp, RankDown, NewMembersHeading, NewMember,
+		LeaderboardTitle, LeaderboardLine, AdminOnly, AdminUsage, AdminPaused, AdminResumed, AdminChannelSet, AdminAliasSet, AdminAliasCleared, AdminLinked, AdminUnlinked, AdminStatus, AdminStatusFetch, AdminStatusChannel, AdminStatusPaused, AdminStatusError, AdminStatusNoErrors, CommandAdmin, RemindUsage, RemindAtSet, RemindBeforeSet, RemindPassedSet, RemindList, RemindNone, RemindOff, RemindNeedsLink, RemindAtMessage, RemindBeforeMessage, RemindPassedMessage, CommandRemind, StreakMilestone, StreakNudge, VsTitle, VsDay, VsPart, VsPartOnly, VsPartTie, VsRecord, VsNone, VsUsage, CommandVs, StarsTitle, StarsHeader, StarsWeek, StarsUsage, StarsNoDays, NoUpdates, UpdateFailed,
+		UpdateCooldown, CommandCooldown, CommandInProgress, HelpHeading, HelpLine, CommandLeaderboard, CommandUpdate,
+		CommandStars, CommandHelp, CommandTimes, CommandLink, CommandUnlink,
+		CommandTimezone, StarEntry, TimesHeading, TimesLine, TimesNone, TimesMore,
+		TimesUsage, LinkUsage, LinkNotFound, LinkTaken, Linked, Unlinked,
+		TimezoneUsage, TimezoneInvalid, TimezoneSet, ProfileError, ThreadName, AllTimeTitle, AllTimeLine, AllTimeUsage, YearUnknown, CommandAllTime, ConfigReloaded, LeaderboardColor, StarsColor,
+		UpdatesColor,
+	} {
+		assert.Contains(t, names, name, "Every message name should have a template")
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "aocbot"
+
+var (
+	FetchAttempts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "aoc_fetch_attempts_total",
+		Help:      "Number of Advent of Code leaderboard fetches attempted.",
+	})
+
+	FetchFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "aoc_fetch_failures_total",
+		Help:      "Number of failed Advent of Code leaderboard fetches by error type.",
+	}, []string{"type"})
+
+	FetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "aoc_fetch_duration_seconds",
+		Help:      "Latency of Advent of Code leaderboard fetches.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	StarsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stars_detected_total",
+		Help:      "Number of new stars detected per leaderboard.",
+	}, []string{"leaderboard"})
+
+	MembersDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "members_detected_total",
+		Help:      "Number of new members detected per leaderboard.",
+	}, []string{"leaderboard"})
+
+	LastSuccessfulFetch = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_fetch_timestamp_seconds",
+		Help:      "Unix time of the last successful leaderboard fetch.",
+	}, []string{"leaderboard"})
+
+	TrackedMembers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracked_members",
+		Help:      "Number of members on the tracked leaderboard.",
+	}, []string{"leaderboard"})
+
+	DiscordSends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discord_messages_sent_total",
+		Help:      "Number of Discord messages sent by kind.",
+	}, []string{"kind"})
+
+	DiscordSendFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discord_message_failures_total",
+		Help:      "Number of Discord messages that failed to send by kind.",
+	}, []string{"kind"})
+
+	CommandInvocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "command_invocations_total",
+		Help:      "Number of bot commands invoked by name.",
+	}, []string{"command"})
+)
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
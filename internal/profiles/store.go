@@ -0,0 +1,165 @@
+// Package profiles stores what the bot knows about Discord users: which
+// leaderboard member they are and their personal preferences.
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const profilesFile = "profiles.json"
+
+// Profile is a Discord user's link to a leaderboard member and their
+// preferences. A zero field means it has not been set.
+type Profile struct {
+	MemberID int    `json:"member_id,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// RemindAt is a time of day such as "20:00" in the user's timezone at
+	// which they get a DM if they have not finished the current puzzle.
+	RemindAt string `json:"remind_at,omitempty"`
+	// RemindBefore is how many minutes before each unlock the user gets a DM.
+	RemindBefore int `json:"remind_before,omitempty"`
+	// RemindPassed asks for a DM when someone passes the user's member on
+	// the leaderboard.
+	RemindPassed bool `json:"remind_passed,omitempty"`
+}
+
+// Linked reports whether the user has linked a leaderboard member.
+func (p Profile) Linked() bool {
+	return p.MemberID != 0
+}
+
+// HasReminders reports whether the user has subscribed to any reminder.
+func (p Profile) HasReminders() bool {
+	return p.RemindAt != "" || p.RemindBefore != 0 || p.RemindPassed
+}
+
+// Location returns the user's timezone, or nil if they have not set a valid
+// one.
+func (p Profile) Location() *time.Location {
+	if p.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// Store keeps profiles by Discord user ID in a JSON file in the data
+// directory. It is safe for concurrent use.
+type Store struct {
+	path     string
+	mu       sync.RWMutex
+	profiles map[string]Profile
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		path:     filepath.Join(dir, profilesFile),
+		profiles: make(map[string]Profile),
+	}
+}
+
+// Load reads the stored profiles. A missing file is not an error, it just
+// means nobody has set up a profile yet.
+func (s *Store) Load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading profiles: %w", err)
+	}
+
+	profiles := make(map[string]Profile)
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("error unmarshalling profiles %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles = profiles
+	return nil
+}
+
+// Get returns the profile of a Discord user.
+func (s *Store) Get(userID string) (Profile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profile, ok := s.profiles[userID]
+	return profile, ok
+}
+
+// All returns a copy of every profile by Discord user ID.
+func (s *Store) All() map[string]Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profiles := make(map[string]Profile, len(s.profiles))
+	for userID, profile := range s.profiles {
+		profiles[userID] = profile
+	}
+	return profiles
+}
+
+// UserForMember returns the Discord user linked to a leaderboard member.
+func (s *Store) UserForMember(memberID int) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for userID, profile := range s.profiles {
+		if profile.MemberID == memberID {
+			return userID, true
+		}
+	}
+	return "", false
+}
+
+// Update changes a user's profile with fn and saves the store. A profile
+// left empty is removed. If saving fails the change is undone.
+func (s *Store) Update(userID string, fn func(*Profile)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.profiles[userID]
+	profile := previous
+	fn(&profile)
+	if profile == (Profile{}) {
+		delete(s.profiles, userID)
+	} else {
+		s.profiles[userID] = profile
+	}
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.profiles[userID] = previous
+		} else {
+			delete(s.profiles, userID)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the profiles atomically. The caller must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling profiles: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("error storing profiles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreUpdateAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	err := store.Update("user-1", func(p *Profile) {
+		p.MemberID = 42
+		p.Timezone = "Europe/Paris"
+	})
+	assert.NoError(t, err, "Update should not return an error")
+
+	reloaded := NewStore(dir)
+	assert.NoError(t, reloaded.Load(), "Load should not return an error")
+
+	profile, ok := reloaded.Get("user-1")
+	assert.True(t, ok, "Profile should be stored")
+	assert.Equal(t, Profile{MemberID: 42, Timezone: "Europe/Paris"}, profile, "Profile should match")
+
+	userID, ok := reloaded.UserForMember(42)
+	assert.True(t, ok, "Member should be linked")
+	assert.Equal(t, "user-1", userID, "Member should be linked to the user")
+}
+
+func TestStoreRemovesEmptyProfile(t *testing.T) {
+	store := NewStore(t.TempDir())
+	assert.NoError(t, store.Update("user-1", func(p *Profile) { p.MemberID = 42 }))
+
+	assert.NoError(t, store.Update("user-1", func(p *Profile) { p.MemberID = 0 }))
+
+	_, ok := store.Get("user-1")
+	assert.False(t, ok, "An empty profile should be removed")
+	assert.Empty(t, store.All(), "No profiles should be left")
+}
+
+func TestStoreLoadMissingFile(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	assert.NoError(t, store.Load(), "A missing file should not be an error")
+	assert.Empty(t, store.All(), "There should be no profiles")
+}
+
+func TestStoreLoadCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, profilesFile), []byte("{"), 0o644))
+
+	err := NewStore(dir).Load()
+
+	assert.Error(t, err, "A corrupt file should be reported")
+}
+
+func TestStoreUpdateUndoneOnSaveFailure(t *testing.T) {
+	dir := t.TempDir()
+	// A file where the data directory should be makes saving fail.
+	blocked := filepath.Join(dir, "blocked")
+	assert.NoError(t, os.WriteFile(blocked, nil, 0o644))
+	store := NewStore(filepath.Join(blocked, "data"))
+
+	err := store.Update("user-1", func(p *Profile) { p.MemberID = 42 })
+
+	assert.Error(t, err, "Update should report the save failure")
+	_, ok := store.Get("user-1")
+	assert.False(t, ok, "The change should be undone")
+}
+
+func TestProfileLocation(t *testing.T) {
+	assert.Nil(t, Profile{}.Location(), "No timezone should give no location")
+	assert.Nil(t, Profile{Timezone: "Mars/Olympus_Mons"}.Location(), "An unknown timezone should give no location")
+	assert.Equal(t, "Europe/Paris", Profile{Timezone: "Europe/Paris"}.Location().String(), "Location should match the timezone")
+}
@@ -0,0 +1,123 @@
+// Package reminders works out when members who subscribed to direct message
+// reminders should get them.
+package reminders
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+)
+
+// MaxBefore is the longest time before an unlock a reminder can be asked
+// for.
+const MaxBefore = 24 * time.Hour
+
+// ParseClock parses a time of day such as "20:00" or "8:30" and returns it
+// in the canonical "20:00" form.
+func ParseClock(s string) (string, bool) {
+	hour, minute, ok := clock(s)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(hour) + ":" + twoDigits(minute), true
+}
+
+func clock(s string) (hour, minute int, ok bool) {
+	h, m, found := strings.Cut(s, ":")
+	if !found || len(m) != 2 {
+		return 0, 0, false
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+	minute, err = strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// NextAt returns the first time after the given one that the time of day
+// at comes round in loc while a puzzle of the event is current, and that
+// puzzle's day.
+func NextAt(calendar aoc.Calendar, at string, loc *time.Location, after time.Time) (day int, remind time.Time, ok bool) {
+	hour, minute, ok := clock(at)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	start := after
+	if first := calendar.Unlock(1); start.Before(first) {
+		start = first
+	}
+	start = start.In(loc)
+	for i := 0; ; i++ {
+		remind = time.Date(start.Year(), start.Month(), start.Day()+i, hour, minute, 0, 0, loc)
+		if remind.After(calendar.End()) {
+			return 0, time.Time{}, false
+		}
+		if day := calendar.CurrentDay(remind); remind.After(after) && day != 0 {
+			return day, remind, true
+		}
+	}
+}
+
+// NextBefore returns the first time after the given one that is the given
+// time before a puzzle unlocks, and the day of that puzzle.
+func NextBefore(calendar aoc.Calendar, before time.Duration, after time.Time) (day int, remind time.Time, ok bool) {
+	for day := 1; day <= calendar.Days; day++ {
+		if remind := calendar.Unlock(day).Add(-before); remind.After(after) {
+			return day, remind, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+// Overtakers returns the names of the members who were behind or level with
+// a member on local score in previous and are ahead of them in current,
+// highest score first.
+func Overtakers(previous, current *aoc.Leaderboard, memberID int) []string {
+	if previous == nil || current == nil {
+		return nil
+	}
+	key := strconv.Itoa(memberID)
+	before, ok := previous.Members[key]
+	if !ok {
+		return nil
+	}
+	now, ok := current.Members[key]
+	if !ok {
+		return nil
+	}
+
+	var passed []aoc.Member
+	for id, member := range current.Members {
+		if id == key || member.LocalScore <= now.LocalScore {
+			continue
+		}
+		if was, ok := previous.Members[id]; ok && was.LocalScore <= before.LocalScore {
+			passed = append(passed, member)
+		}
+	}
+	sort.Slice(passed, func(i, j int) bool {
+		if passed[i].LocalScore != passed[j].LocalScore {
+			return passed[i].LocalScore > passed[j].LocalScore
+		}
+		return passed[i].Name < passed[j].Name
+	})
+	names := make([]string, len(passed))
+	for i, member := range passed {
+		names[i] = member.Name
+	}
+	return names
+}
@@ -0,0 +1,76 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+var calendar2024 = aoc.CalendarFor(2024)
+
+func TestParseClock(t *testing.T) {
+	for input, expected := range map[string]string{"20:00": "20:00", "8:30": "8:30", "08:05": "8:05", "0:00": "0:00"} {
+		clock, ok := ParseClock(input)
+		assert.True(t, ok, "%s should be a valid time", input)
+		assert.Equal(t, expected, clock, "%s should be canonical", input)
+	}
+	for _, input := range []string{"24:00", "20:60", "20", "8:5", "evening", ""} {
+		_, ok := ParseClock(input)
+		assert.False(t, ok, "%s should not be a valid time", input)
+	}
+}
+
+func TestNextAt(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+
+	day, remind, ok := NextAt(calendar2024, "20:00", paris, time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok, "There should be a reminder before the event")
+	assert.Equal(t, 1, day, "The first reminder should be for day 1")
+	assert.Equal(t, time.Date(2024, time.December, 1, 20, 0, 0, 0, paris), remind, "The first reminder should be on the evening of day 1")
+
+	day, remind, ok = NextAt(calendar2024, "5:00", paris, time.Date(2024, time.December, 3, 5, 0, 0, 0, paris))
+	assert.True(t, ok, "There should be a reminder during the event")
+	assert.Equal(t, 3, day, "At 5:00 in Paris day 3 is still current")
+	assert.Equal(t, time.Date(2024, time.December, 4, 5, 0, 0, 0, paris), remind, "The reminder should be strictly after the given time")
+
+	_, _, ok = NextAt(calendar2024, "20:00", paris, calendar2024.End())
+	assert.False(t, ok, "There should be no reminder after the event")
+
+	_, _, ok = NextAt(calendar2024, "soon", paris, calendar2024.Unlock(1))
+	assert.False(t, ok, "An invalid time should never remind")
+}
+
+func TestNextBefore(t *testing.T) {
+	day, remind, ok := NextBefore(calendar2024, 15*time.Minute, calendar2024.Unlock(3))
+	assert.True(t, ok, "There should be a reminder before the next unlock")
+	assert.Equal(t, 4, day, "The reminder should be for the next day")
+	assert.Equal(t, calendar2024.Unlock(4).Add(-15*time.Minute), remind, "The reminder should come 15 minutes early")
+
+	_, _, ok = NextBefore(calendar2024, 15*time.Minute, calendar2024.Unlock(25))
+	assert.False(t, ok, "There should be no reminder after the last unlock")
+}
+
+func TestOvertakers(t *testing.T) {
+	previous := &aoc.Leaderboard{Members: map[string]aoc.Member{
+		"1": {ID: 1, Name: "Alice", LocalScore: 50},
+		"2": {ID: 2, Name: "Bob", LocalScore: 40},
+		"3": {ID: 3, Name: "Charlie", LocalScore: 50},
+		"4": {ID: 4, Name: "Dana", LocalScore: 60},
+	}}
+	current := &aoc.Leaderboard{Members: map[string]aoc.Member{
+		"1": {ID: 1, Name: "Alice", LocalScore: 55},
+		"2": {ID: 2, Name: "Bob", LocalScore: 58},
+		"3": {ID: 3, Name: "Charlie", LocalScore: 70},
+		"4": {ID: 4, Name: "Dana", LocalScore: 65},
+		"5": {ID: 5, Name: "Eve", LocalScore: 90},
+	}}
+
+	assert.Equal(t, []string{"Charlie", "Bob"}, Overtakers(previous, current, 1), "Members who were behind or level and are now ahead should be listed")
+	assert.Equal(t, []string{"Charlie"}, Overtakers(previous, current, 4), "Charlie passed Dana")
+	assert.Empty(t, Overtakers(previous, current, 2), "Nobody who was behind Bob passed him")
+	assert.Empty(t, Overtakers(previous, current, 5), "New members have not been passed")
+	assert.Empty(t, Overtakers(nil, current, 1), "Without a previous leaderboard nobody passed anyone")
+}
@@ -0,0 +1,156 @@
+// Package roles works out which Discord roles a linked member has earned on
+// the leaderboard. It only decides; applying the roles is up to the caller.
+package roles
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+)
+
+// Kind is what a member has to do to earn a role.
+type Kind string
+
+const (
+	// Stars is earned with at least Rule.Stars stars.
+	Stars Kind = "stars"
+	// Leader is earned by the members with the highest local score.
+	Leader Kind = "leader"
+	// SolvedToday is earned by solving both parts of the current puzzle, and
+	// is lost when the next one unlocks.
+	SolvedToday Kind = "solved_today"
+)
+
+// Rule maps a Discord role to what it takes to earn it in one year's event.
+// A Year of zero means the tracked year, AOC_YEAR.
+type Rule struct {
+	RoleID string `json:"role_id"`
+	Kind   Kind   `json:"kind"`
+	Stars  int    `json:"stars,omitempty"`
+	Year   int    `json:"year,omitempty"`
+}
+
+// EventYear returns the year of the event the role is earned in, given the
+// tracked year.
+func (r Rule) EventYear(tracked int) int {
+	if r.Year == 0 {
+		return tracked
+	}
+	return r.Year
+}
+
+// Validate checks that every rule is complete and that no role is managed by
+// two rules. Star counts are checked against the number of puzzles of each
+// rule's event, given the tracked year.
+func Validate(rules []Rule, tracked int) error {
+	seen := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if rule.RoleID == "" {
+			return fmt.Errorf("role rule %d has no role_id", i+1)
+		}
+		if seen[rule.RoleID] {
+			return fmt.Errorf("role %s has more than one rule", rule.RoleID)
+		}
+		seen[rule.RoleID] = true
+
+		if rule.Year != 0 && rule.Year < aoc.FirstEvent {
+			return fmt.Errorf("role %s is for %d, before the first event in %d", rule.RoleID, rule.Year, aoc.FirstEvent)
+		}
+		year := rule.EventYear(tracked)
+
+		switch rule.Kind {
+		case Stars:
+			if maxStars := 2 * aoc.CalendarFor(year).Days; rule.Stars < 1 || rule.Stars > maxStars {
+				return fmt.Errorf("role %s needs a star count between 1 and %d for %d", rule.RoleID, maxStars, year)
+			}
+		case Leader, SolvedToday:
+		case "":
+			return fmt.Errorf("role %s has no kind", rule.RoleID)
+		default:
+			return fmt.Errorf("role %s has unknown kind %q", rule.RoleID, rule.Kind)
+		}
+	}
+	return nil
+}
+
+// Decidable returns the rules whose event has a leaderboard among
+// leaderboards, by year, given the tracked year. The others cannot be decided
+// yet, so their roles should be left as they are.
+func Decidable(rules []Rule, leaderboards map[int]*aoc.Leaderboard, tracked int) []Rule {
+	var decidable []Rule
+	for _, rule := range rules {
+		if leaderboards[rule.EventYear(tracked)] != nil {
+			decidable = append(decidable, rule)
+		}
+	}
+	return decidable
+}
+
+// Desired returns the roles the member with memberID has earned at now, each
+// rule being decided on the leaderboard of its event among leaderboards, by
+// year, given the tracked year. Roles not earned are left out.
+func Desired(rules []Rule, leaderboards map[int]*aoc.Leaderboard, tracked int, memberID int, now time.Time) map[string]bool {
+	desired := make(map[string]bool)
+	for _, rule := range rules {
+		leaderboard := leaderboards[rule.EventYear(tracked)]
+		if leaderboard == nil {
+			continue
+		}
+		member, ok := leaderboard.Members[strconv.Itoa(memberID)]
+		if ok && earned(rule, leaderboard, member, now) {
+			desired[rule.RoleID] = true
+		}
+	}
+	return desired
+}
+
+func earned(rule Rule, leaderboard *aoc.Leaderboard, member aoc.Member, now time.Time) bool {
+	switch rule.Kind {
+	case Stars:
+		return member.Stars >= rule.Stars
+	case Leader:
+		if member.LocalScore == 0 {
+			return false
+		}
+		for _, other := range leaderboard.Members {
+			if other.LocalScore > member.LocalScore {
+				return false
+			}
+		}
+		return true
+	case SolvedToday:
+		year, err := strconv.Atoi(leaderboard.Event)
+		if err != nil {
+			return false
+		}
+		day := aoc.CalendarFor(year).CurrentDay(now)
+		if day == 0 {
+			return false
+		}
+		level, ok := member.CompletionDayLevels[strconv.Itoa(day)]
+		return ok && level.Level1 != nil && level.Level2 != nil
+	}
+	return false
+}
+
+// Plan works out which managed roles to add and remove so that a user who
+// has the current roles ends up with the desired ones. Roles that no rule
+// manages are never touched.
+func Plan(rules []Rule, desired map[string]bool, current []string) (add, remove []string) {
+	has := make(map[string]bool, len(current))
+	for _, roleID := range current {
+		has[roleID] = true
+	}
+
+	for _, rule := range rules {
+		switch {
+		case desired[rule.RoleID] && !has[rule.RoleID]:
+			add = append(add, rule.RoleID)
+		case !desired[rule.RoleID] && has[rule.RoleID]:
+			remove = append(remove, rule.RoleID)
+		}
+	}
+	return add, remove
+}
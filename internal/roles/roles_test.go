@@ -0,0 +1,132 @@
+package roles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRules = []Rule{
+	{RoleID: "role-25", Kind: Stars, Stars: 25},
+	{RoleID: "role-50", Kind: Stars, Stars: 50},
+	{RoleID: "role-leader", Kind: Leader},
+	{RoleID: "role-today", Kind: SolvedToday},
+}
+
+func star() *aoc.StarDetail {
+	return &aoc.StarDetail{GetStarTs: 1}
+}
+
+func testLeaderboard() *aoc.Leaderboard {
+	return &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Alice", Stars: 30, LocalScore: 500, CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"5": {Level1: star(), Level2: star()},
+			}},
+			"2": {ID: 2, Name: "Bob", Stars: 50, LocalScore: 400, CompletionDayLevels: map[string]aoc.CompletionDayLevel{
+				"5": {Level1: star()},
+			}},
+			"3": {ID: 3, Name: "Charlie"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(testRules, 2024), "Valid rules should be accepted")
+	assert.NoError(t, Validate([]Rule{{RoleID: "role", Kind: Stars, Stars: 50, Year: 2024}}, 2025), "A rule for a past year should be checked against that year")
+
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"Missing Role", []Rule{{Kind: Leader}}},
+		{"Missing Kind", []Rule{{RoleID: "role"}}},
+		{"Unknown Kind", []Rule{{RoleID: "role", Kind: "fastest"}}},
+		{"No Star Count", []Rule{{RoleID: "role", Kind: Stars}}},
+		{"Too Many Stars", []Rule{{RoleID: "role", Kind: Stars, Stars: 51}}},
+		{"Too Many Stars For 2025", []Rule{{RoleID: "role", Kind: Stars, Stars: 25, Year: 2025}}},
+		{"Before The First Event", []Rule{{RoleID: "role", Kind: Leader, Year: 2014}}},
+		{"Duplicate Role", []Rule{{RoleID: "role", Kind: Leader}, {RoleID: "role", Kind: SolvedToday}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Validate(tt.rules, 2024), "Invalid rules should be rejected")
+		})
+	}
+}
+
+func TestDesired(t *testing.T) {
+	day5 := aoc.UnlockTime(2024, 5).Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		memberID int
+		now      time.Time
+		expected map[string]bool
+	}{
+		{"Leader Who Solved Today", 1, day5, map[string]bool{"role-25": true, "role-leader": true, "role-today": true}},
+		{"All Stars Without Today", 2, day5, map[string]bool{"role-25": true, "role-50": true}},
+		{"Today Reset At Next Unlock", 1, aoc.UnlockTime(2024, 6), map[string]bool{"role-25": true, "role-leader": true}},
+		{"No Score Is Not A Leader", 3, day5, map[string]bool{}},
+		{"Unknown Member", 99, day5, map[string]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := Desired(testRules, map[int]*aoc.Leaderboard{2024: testLeaderboard()}, 2024, tt.memberID, tt.now)
+
+			assert.Equal(t, tt.expected, desired, "Desired roles should match")
+		})
+	}
+}
+
+func TestDesired_TiedLeaders(t *testing.T) {
+	leaderboard := testLeaderboard()
+	bob := leaderboard.Members["2"]
+	bob.LocalScore = 500
+	leaderboard.Members["2"] = bob
+
+	leaderboards := map[int]*aoc.Leaderboard{2024: leaderboard}
+
+	assert.True(t, Desired(testRules, leaderboards, 2024, 1, time.Time{})["role-leader"], "Alice should share the lead")
+	assert.True(t, Desired(testRules, leaderboards, 2024, 2, time.Time{})["role-leader"], "Bob should share the lead")
+}
+
+func TestDesired_PastYears(t *testing.T) {
+	rules := []Rule{
+		{RoleID: "role-2024-all", Kind: Stars, Stars: 50, Year: 2024},
+		{RoleID: "role-all", Kind: Stars, Stars: 24},
+	}
+	current := &aoc.Leaderboard{Event: "2025", Members: map[string]aoc.Member{
+		"2": {ID: 2, Name: "Bob", Stars: 10},
+	}}
+	leaderboards := map[int]*aoc.Leaderboard{2024: testLeaderboard(), 2025: current}
+
+	desired := Desired(rules, leaderboards, 2025, 2, time.Time{})
+
+	assert.Equal(t, map[string]bool{"role-2024-all": true}, desired, "Roles of past years should still be earned after the tracked year moved on")
+}
+
+func TestDecidable(t *testing.T) {
+	rules := []Rule{
+		{RoleID: "role-2023", Kind: Leader, Year: 2023},
+		{RoleID: "role-2024", Kind: Leader, Year: 2024},
+		{RoleID: "role-tracked", Kind: Leader},
+	}
+
+	decidable := Decidable(rules, map[int]*aoc.Leaderboard{2024: testLeaderboard()}, 2024)
+
+	assert.Equal(t, rules[1:], decidable, "Rules without a leaderboard for their year should be left out")
+}
+
+func TestPlan(t *testing.T) {
+	desired := map[string]bool{"role-25": true, "role-leader": true}
+	current := []string{"role-25", "role-today", "unmanaged"}
+
+	add, remove := Plan(testRules, desired, current)
+
+	assert.Equal(t, []string{"role-leader"}, add, "Missing roles should be added")
+	assert.Equal(t, []string{"role-today"}, remove, "Roles no longer earned should be removed")
+}
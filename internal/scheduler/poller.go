@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Poller runs a job at a fixed interval and keeps track of when it will run
+// next.
+type Poller struct {
+	job      func(ctx context.Context)
+	interval time.Duration
+	next     time.Time
+	reset    chan struct{}
+	mu       sync.RWMutex
+}
+
+func NewPoller(interval time.Duration, job func(ctx context.Context)) *Poller {
+	return &Poller{
+		job:      job,
+		interval: interval,
+		reset:    make(chan struct{}, 1),
+	}
+}
+
+// Run calls the job every interval until ctx is cancelled. The job receives
+// ctx so that a run in progress is aborted on shutdown.
+func (p *Poller) Run(ctx context.Context) error {
+	timer := time.NewTimer(p.schedule())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.reset:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(p.schedule())
+		case <-timer.C:
+			p.job(ctx)
+			timer.Reset(p.schedule())
+		}
+	}
+}
+
+// schedule records the time of the next run and returns how long to wait.
+func (p *Poller) schedule() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next = time.Now().Add(p.interval)
+	return p.interval
+}
+
+// NextRun returns when the job will run next, or the zero time if the poller
+// has not been started.
+func (p *Poller) NextRun() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.next
+}
+
+func (p *Poller) Interval() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.interval
+}
+
+// SetInterval changes the interval. The next run is rescheduled from now.
+func (p *Poller) SetInterval(interval time.Duration) {
+	p.mu.Lock()
+	changed := p.interval != interval
+	p.interval = interval
+	p.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	select {
+	case p.reset <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollerRunsJob(t *testing.T) {
+	var runs int32
+	poller := NewPoller(10*time.Millisecond, func(context.Context) {
+		atomic.AddInt32(&runs, 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		poller.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 2
+	}, time.Second, 5*time.Millisecond, "Job should run repeatedly")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should return once the context is cancelled")
+	}
+}
+
+func TestPollerNextRun(t *testing.T) {
+	poller := NewPoller(time.Hour, func(context.Context) {})
+
+	assert.True(t, poller.NextRun().IsZero(), "NextRun should be zero before the poller starts")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		return !poller.NextRun().IsZero()
+	}, time.Second, 5*time.Millisecond, "NextRun should be set once the poller starts")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), poller.NextRun(), time.Second, "NextRun should be one interval away")
+}
+
+func TestPollerSetInterval(t *testing.T) {
+	var runs int32
+	poller := NewPoller(time.Hour, func(context.Context) {
+		atomic.AddInt32(&runs, 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		return !poller.NextRun().IsZero()
+	}, time.Second, 5*time.Millisecond, "Poller should start")
+
+	poller.SetInterval(10 * time.Millisecond)
+
+	assert.Equal(t, 10*time.Millisecond, poller.Interval(), "Interval should be updated")
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 1
+	}, time.Second, 5*time.Millisecond, "Job should run on the new interval")
+}
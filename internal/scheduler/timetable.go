@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// recheckInterval is how long a timetable with nothing scheduled waits
+// before asking again, e.g. in case the configured event changed.
+const recheckInterval = time.Hour
+
+// Timetable runs a job at the times given by a next function, such as every
+// puzzle unlock.
+type Timetable struct {
+	next func(after time.Time) (time.Time, bool)
+	job  func(ctx context.Context, at time.Time)
+	wake chan struct{}
+}
+
+// NewTimetable creates a timetable. next returns the first time after the
+// given one that the job should run, or false if there is none for now.
+func NewTimetable(next func(after time.Time) (time.Time, bool), job func(ctx context.Context, at time.Time)) *Timetable {
+	return &Timetable{next: next, job: job, wake: make(chan struct{}, 1)}
+}
+
+// Wake makes a running timetable ask next again, e.g. because an earlier
+// time has been added to the schedule.
+func (t *Timetable) Wake() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run calls the job at each scheduled time until ctx is cancelled. The job
+// receives the time it was scheduled for.
+func (t *Timetable) Run(ctx context.Context) error {
+	for {
+		now := time.Now()
+		at, ok := t.next(now)
+		wait := recheckInterval
+		if ok {
+			wait = at.Sub(now)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-t.wake:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		if ok {
+			t.job(ctx, at)
+		}
+	}
+}
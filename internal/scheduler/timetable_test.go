@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimetableRunsJobAtScheduledTimes(t *testing.T) {
+	start := time.Now()
+	schedule := []time.Time{start.Add(10 * time.Millisecond), start.Add(20 * time.Millisecond)}
+
+	var mu sync.Mutex
+	var ran []time.Time
+	timetable := NewTimetable(func(after time.Time) (time.Time, bool) {
+		for _, at := range schedule {
+			if at.After(after) {
+				return at, true
+			}
+		}
+		return time.Time{}, false
+	}, func(ctx context.Context, at time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, at)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go timetable.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(ran) == 2
+	}, time.Second, 5*time.Millisecond, "Job should run at each scheduled time")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, schedule, ran, "Job should receive the scheduled times")
+}
+
+func TestTimetableStopsOnCancel(t *testing.T) {
+	timetable := NewTimetable(func(after time.Time) (time.Time, bool) {
+		return time.Time{}, false
+	}, func(ctx context.Context, at time.Time) {
+		t.Error("Job should not run without a scheduled time")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		timetable.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should return once the context is cancelled")
+	}
+}
+
+func TestTimetableWake(t *testing.T) {
+	var mu sync.Mutex
+	var schedule []time.Time
+	ran := make(chan time.Time, 1)
+	timetable := NewTimetable(func(after time.Time) (time.Time, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, at := range schedule {
+			if at.After(after) {
+				return at, true
+			}
+		}
+		return time.Time{}, false
+	}, func(ctx context.Context, at time.Time) {
+		ran <- at
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go timetable.Run(ctx)
+
+	mu.Lock()
+	at := time.Now().Add(10 * time.Millisecond)
+	schedule = append(schedule, at)
+	mu.Unlock()
+	timetable.Wake()
+
+	select {
+	case got := <-ran:
+		assert.Equal(t, at, got, "Job should run at the time added after waking")
+	case <-time.After(time.Second):
+		t.Error("Job should run without waiting for the recheck interval")
+	}
+}
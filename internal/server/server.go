@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+)
+
+// StatusSource reports the state of the leaderboard tracker.
+type StatusSource interface {
+	Status() leaderboard.Status
+}
+
+// Schedule reports when the next leaderboard poll will happen.
+type Schedule interface {
+	NextRun() time.Time
+	Interval() time.Duration
+}
+
+// Server exposes health, readiness, status and metrics endpoints over HTTP.
+type Server struct {
+	Tracker        StatusSource
+	Poller         Schedule
+	SessionReady   func() bool
+	ReadyIntervals int
+
+	httpServer *http.Server
+}
+
+type statusResponse struct {
+	Ready            bool       `json:"ready"`
+	DiscordConnected bool       `json:"discord_connected"`
+	LastUpdate       *time.Time `json:"last_update"`
+	LastSuccess      *time.Time `json:"last_success"`
+	LastError        string     `json:"last_error,omitempty"`
+	MemberCount      int        `json:"member_count"`
+	NextPoll         *time.Time `json:"next_poll"`
+}
+
+func NewServer(addr string, tracker StatusSource, poller Schedule, sessionReady func() bool, readyIntervals int) *Server {
+	s := &Server{
+		Tracker:        tracker,
+		Poller:         poller,
+		SessionReady:   sessionReady,
+		ReadyIntervals: readyIntervals,
+	}
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.Handle("/metrics", metrics.Handler())
+	return mux
+}
+
+// shutdownGrace is how long in-flight requests get to finish on shutdown.
+const shutdownGrace = 5 * time.Second
+
+// Run serves HTTP requests until ctx is cancelled, then shuts the server down
+// gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP server listening on %s", s.httpServer.Addr)
+		errs <- s.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("error running HTTP server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error shutting down HTTP server: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !s.ready(s.Tracker.Status()) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := s.Tracker.Status()
+
+	resp := statusResponse{
+		Ready:            s.ready(status),
+		DiscordConnected: s.SessionReady(),
+		LastUpdate:       timeOrNil(status.LastUpdate),
+		LastSuccess:      timeOrNil(status.LastSuccess),
+		MemberCount:      status.MemberCount,
+		NextPoll:         timeOrNil(s.Poller.NextRun()),
+	}
+	if status.LastError != nil {
+		resp.LastError = status.LastError.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("error writing status response: %v", err)
+	}
+}
+
+// ready reports whether the Discord session is open and the last successful
+// AoC fetch happened within the allowed number of poll intervals.
+func (s *Server) ready(status leaderboard.Status) bool {
+	if !s.SessionReady() || status.LastSuccess.IsZero() {
+		return false
+	}
+	window := time.Duration(s.ReadyIntervals) * s.Poller.Interval()
+	return time.Since(status.LastSuccess) <= window
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/leaderboard"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTracker struct {
+	status leaderboard.Status
+}
+
+func (f *fakeTracker) Status() leaderboard.Status {
+	return f.status
+}
+
+type fakePoller struct {
+	next     time.Time
+	interval time.Duration
+}
+
+func (f *fakePoller) NextRun() time.Time {
+	return f.next
+}
+
+func (f *fakePoller) Interval() time.Duration {
+	return f.interval
+}
+
+func newTestServer(status leaderboard.Status, sessionReady bool) *Server {
+	poller := &fakePoller{
+		next:     time.Date(2024, 12, 1, 5, 15, 0, 0, time.UTC),
+		interval: 15 * time.Minute,
+	}
+	return NewServer(":0", &fakeTracker{status: status}, poller, func() bool { return sessionReady }, 3)
+}
+
+func get(t *testing.T, s *Server, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHealthz(t *testing.T) {
+	s := newTestServer(leaderboard.Status{}, false)
+
+	rec := get(t, s, "/healthz")
+
+	assert.Equal(t, http.StatusOK, rec.Code, "Healthz should always succeed")
+}
+
+func TestReadyz(t *testing.T) {
+	t.Run("Ready", func(t *testing.T) {
+		s := newTestServer(leaderboard.Status{LastSuccess: time.Now().Add(-20 * time.Minute)}, true)
+
+		rec := get(t, s, "/readyz")
+
+		assert.Equal(t, http.StatusOK, rec.Code, "Should be ready with an open session and a recent fetch")
+	})
+
+	t.Run("Session Not Open", func(t *testing.T) {
+		s := newTestServer(leaderboard.Status{LastSuccess: time.Now()}, false)
+
+		rec := get(t, s, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Should not be ready without a Discord session")
+	})
+
+	t.Run("No Successful Fetch", func(t *testing.T) {
+		s := newTestServer(leaderboard.Status{}, true)
+
+		rec := get(t, s, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Should not be ready before the first fetch")
+	})
+
+	t.Run("Stale Fetch", func(t *testing.T) {
+		s := newTestServer(leaderboard.Status{LastSuccess: time.Now().Add(-46 * time.Minute)}, true)
+
+		rec := get(t, s, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Should not be ready when the last fetch is older than three intervals")
+	})
+}
+
+func TestStatus(t *testing.T) {
+	lastUpdate := time.Date(2024, 12, 1, 5, 0, 0, 0, time.UTC)
+	s := newTestServer(leaderboard.Status{
+		LastUpdate:  lastUpdate,
+		LastSuccess: lastUpdate,
+		LastError:   errors.New("API error"),
+		MemberCount: 12,
+	}, true)
+
+	rec := get(t, s, "/status")
+
+	assert.Equal(t, http.StatusOK, rec.Code, "Status should succeed")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "Status should be JSON")
+
+	var body map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.NoError(t, err, "Status should be valid JSON")
+	assert.Equal(t, "2024-12-01T05:00:00Z", body["last_update"], "last_update should match")
+	assert.Equal(t, "API error", body["last_error"], "last_error should match")
+	assert.Equal(t, float64(12), body["member_count"], "member_count should match")
+	assert.Equal(t, "2024-12-01T05:15:00Z", body["next_poll"], "next_poll should match")
+	assert.Equal(t, true, body["discord_connected"], "discord_connected should match")
+	assert.Equal(t, false, body["ready"], "ready should be false for an old fetch")
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(leaderboard.Status{}, true)
+	metrics.CommandInvocations.WithLabelValues("!help").Inc()
+
+	rec := get(t, s, "/metrics")
+
+	assert.Equal(t, http.StatusOK, rec.Code, "Metrics should succeed")
+	assert.Contains(t, rec.Body.String(), `aocbot_command_invocations_total{command="!help"}`, "Metrics should include the bot's counters")
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	poller := &fakePoller{interval: 15 * time.Minute}
+	s := NewServer("127.0.0.1:0", &fakeTracker{}, poller, func() bool { return true }, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run(ctx)
+	}()
+
+	cancel()
+	select {
+	case err := <-errs:
+		assert.NoError(t, err, "Run should shut down cleanly")
+	case <-time.After(time.Second):
+		t.Fatal("Run should return once the context is cancelled")
+	}
+}
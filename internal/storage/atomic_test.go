@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	err := WriteFileAtomic(path, []byte("first"), 0o600)
+	assert.NoError(t, err, "First write should succeed")
+
+	err = WriteFileAtomic(path, []byte("second"), 0o600)
+	assert.NoError(t, err, "Overwrite should succeed")
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data), "File should contain the latest data")
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "File should have the requested permissions")
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "No temporary files should be left behind")
+}
+
+func TestWriteFileAtomic_MissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "data.json")
+
+	err := WriteFileAtomic(path, []byte("data"), 0o600)
+
+	assert.Error(t, err, "Write should fail when the directory does not exist")
+}
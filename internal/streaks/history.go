@@ -0,0 +1,190 @@
+// Package streaks keeps the star history of every member and works out their
+// solve streaks: runs of consecutive puzzle days on which they got both
+// stars in time.
+package streaks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const historyFile = "history.json"
+
+// Record is the star history of one member in one event, along with what the
+// bot has already told them about their streak.
+type Record struct {
+	Name string `json:"name"`
+	// Completed is when the member got the second star of each day.
+	Completed map[int]time.Time `json:"completed,omitempty"`
+	// Milestone is the last streak milestone announced for the member, and
+	// MilestoneStart the first day of the streak it was announced for.
+	Milestone      int `json:"milestone,omitempty"`
+	MilestoneStart int `json:"milestone_start,omitempty"`
+	// Nudged is the last day the member was nudged to keep their streak.
+	Nudged int `json:"nudged,omitempty"`
+}
+
+// History keeps records by year and AoC member ID in a JSON file in the data
+// directory. Stars are only ever added, so a day stays completed even if a
+// later leaderboard no longer shows it. It is safe for concurrent use.
+type History struct {
+	path    string
+	mu      sync.RWMutex
+	records map[int]map[int]Record
+}
+
+func NewHistory(dir string) *History {
+	return &History{
+		path:    filepath.Join(dir, historyFile),
+		records: make(map[int]map[int]Record),
+	}
+}
+
+// Load reads the stored history. A missing file is not an error, it just
+// means nothing has been recorded yet.
+func (h *History) Load() error {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading star history: %w", err)
+	}
+
+	records := make(map[int]map[int]Record)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("error unmarshalling star history %s: %w", h.path, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = records
+	return nil
+}
+
+// Add records the days each member of the leaderboard has completed and
+// saves the history if anything is new. If saving fails the change is
+// undone.
+func (h *History) Add(leaderboard *aoc.Leaderboard) error {
+	if leaderboard == nil {
+		return nil
+	}
+	year, err := strconv.Atoi(leaderboard.Event)
+	if err != nil {
+		return fmt.Errorf("error recording star history: invalid event %q", leaderboard.Event)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.records[year]
+	records := make(map[int]Record, len(previous))
+	for id, record := range previous {
+		records[id] = record
+	}
+
+	changed := false
+	for _, member := range leaderboard.Members {
+		record := records[member.ID]
+		completed := make(map[int]time.Time, len(record.Completed))
+		for day, at := range record.Completed {
+			completed[day] = at
+		}
+		for key, level := range member.CompletionDayLevels {
+			day, err := strconv.Atoi(key)
+			if err != nil || level.Level2 == nil {
+				continue
+			}
+			if _, ok := completed[day]; !ok {
+				completed[day] = time.Unix(int64(level.Level2.GetStarTs), 0).UTC()
+				changed = true
+			}
+		}
+		if record.Name != member.Name {
+			record.Name = member.Name
+			changed = true
+		}
+		record.Completed = completed
+		records[member.ID] = record
+	}
+	if !changed {
+		return nil
+	}
+
+	h.records[year] = records
+	if err := h.save(); err != nil {
+		h.records[year] = previous
+		return err
+	}
+	return nil
+}
+
+// Get returns the record of a member in an event.
+func (h *History) Get(year, memberID int) (Record, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	record, ok := h.records[year][memberID]
+	return record, ok
+}
+
+// Members returns the records of every member in an event, by member ID.
+func (h *History) Members(year int) map[int]Record {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	records := make(map[int]Record, len(h.records[year]))
+	for id, record := range h.records[year] {
+		records[id] = record
+	}
+	return records
+}
+
+// Update changes the record of a member with fn and saves the history. If
+// saving fails the change is undone.
+func (h *History) Update(year, memberID int, fn func(*Record)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.records[year] == nil {
+		h.records[year] = make(map[int]Record)
+	}
+	records := h.records[year]
+	previous, existed := records[memberID]
+	record := previous
+	fn(&record)
+	records[memberID] = record
+
+	if err := h.save(); err != nil {
+		if existed {
+			records[memberID] = previous
+		} else {
+			delete(records, memberID)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the history atomically. The caller must hold h.mu.
+func (h *History) save() error {
+	data, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling star history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(h.path, data, 0o644); err != nil {
+		return fmt.Errorf("error storing star history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package streaks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func leaderboard(levels map[string]aoc.CompletionDayLevel) *aoc.Leaderboard {
+	return &aoc.Leaderboard{
+		Event: "2024",
+		Members: map[string]aoc.Member{
+			"1": {ID: 1, Name: "Eve", CompletionDayLevels: levels},
+		},
+	}
+}
+
+func TestHistoryAddAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	history := NewHistory(dir)
+
+	err := history.Add(leaderboard(map[string]aoc.CompletionDayLevel{
+		"1": {Level1: &aoc.StarDetail{GetStarTs: 100}, Level2: &aoc.StarDetail{GetStarTs: 200}},
+		"2": {Level1: &aoc.StarDetail{GetStarTs: 300}},
+	}))
+	assert.NoError(t, err, "Add should not return an error")
+
+	// A later leaderboard without day 1 must not erase it.
+	err = history.Add(leaderboard(map[string]aoc.CompletionDayLevel{
+		"2": {Level1: &aoc.StarDetail{GetStarTs: 300}, Level2: &aoc.StarDetail{GetStarTs: 400}},
+	}))
+	assert.NoError(t, err, "Add should not return an error")
+
+	reloaded := NewHistory(dir)
+	assert.NoError(t, reloaded.Load(), "Load should not return an error")
+
+	record, ok := reloaded.Get(2024, 1)
+	assert.True(t, ok, "Record should be stored")
+	assert.Equal(t, "Eve", record.Name, "Name should match")
+	expected := map[int]time.Time{
+		1: time.Unix(200, 0).UTC(),
+		2: time.Unix(400, 0).UTC(),
+	}
+	assert.Equal(t, expected, record.Completed, "Completed days should be kept and added to")
+
+	_, ok = reloaded.Get(2023, 1)
+	assert.False(t, ok, "Records should be kept per year")
+}
+
+func TestHistoryUpdate(t *testing.T) {
+	history := NewHistory(t.TempDir())
+	assert.NoError(t, history.Add(leaderboard(nil)))
+
+	err := history.Update(2024, 1, func(r *Record) {
+		r.Milestone = 5
+		r.MilestoneStart = 1
+	})
+	assert.NoError(t, err, "Update should not return an error")
+
+	record, _ := history.Get(2024, 1)
+	assert.Equal(t, "Eve", record.Name, "Update should keep the rest of the record")
+	assert.Equal(t, 5, record.Milestone, "Milestone should be updated")
+	assert.Len(t, history.Members(2024), 1, "Members should list every record of the year")
+}
+
+func TestHistoryLoad_MissingFile(t *testing.T) {
+	history := NewHistory(t.TempDir())
+
+	assert.NoError(t, history.Load(), "A missing file should not be an error")
+	assert.Empty(t, history.Members(2024), "History should be empty")
+}
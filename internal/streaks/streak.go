@@ -0,0 +1,85 @@
+package streaks
+
+import (
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+)
+
+// Streak is a run of consecutive days, from Start on, that a member got both
+// stars of in time. A zero Length means there is no streak.
+type Streak struct {
+	Start  int
+	Length int
+}
+
+// End returns the last day of the streak.
+func (s Streak) End() int {
+	return s.Start + s.Length - 1
+}
+
+// Deadline returns when a day stops counting towards a streak: the end of
+// that day of December in loc. Without loc the day is counted in the unlock
+// timezone, which makes the deadline the next puzzle's unlock.
+func Deadline(calendar aoc.Calendar, day int, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = aoc.UnlockZone
+	}
+	return time.Date(calendar.Year, time.December, day+1, 0, 0, 0, 0, loc)
+}
+
+// Current returns the streak a member is on at now, given when they
+// completed each day. Days whose deadline has not passed yet do not break the
+// streak, they just do not count until they are completed.
+func Current(calendar aoc.Calendar, completed map[int]time.Time, loc *time.Location, now time.Time) Streak {
+	inTime := func(day int) bool {
+		at, ok := completed[day]
+		return ok && at.Before(Deadline(calendar, day, loc))
+	}
+
+	day := calendar.Unlocked(now)
+	for day > 0 && !inTime(day) && now.Before(Deadline(calendar, day, loc)) {
+		day--
+	}
+	end := day
+	for day > 0 && inTime(day) {
+		day--
+	}
+	if end == day {
+		return Streak{}
+	}
+	return Streak{Start: day + 1, Length: end - day}
+}
+
+// AtRisk returns the day a member has to complete to keep their streak going
+// and its deadline, if that deadline is less than window away at now.
+func AtRisk(calendar aoc.Calendar, completed map[int]time.Time, loc *time.Location, now time.Time, window time.Duration) (day int, deadline time.Time, ok bool) {
+	streak := Current(calendar, completed, loc, now)
+	if streak.Length == 0 {
+		return 0, time.Time{}, false
+	}
+	day = streak.End() + 1
+	if !calendar.IsUnlocked(day, now) {
+		return 0, time.Time{}, false
+	}
+	if _, done := completed[day]; done {
+		return 0, time.Time{}, false
+	}
+	deadline = Deadline(calendar, day, loc)
+	if !now.Before(deadline) || deadline.Sub(now) > window {
+		return 0, time.Time{}, false
+	}
+	return day, deadline, true
+}
+
+// Milestone returns the highest of the milestones a streak of the given
+// length has reached, or 0 if it has reached none.
+func Milestone(milestones []int, length int) int {
+	reached := 0
+	for _, milestone := range milestones {
+		if milestone <= length && milestone > reached {
+			reached = milestone
+		}
+	}
+	return reached
+}
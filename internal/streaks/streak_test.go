@@ -0,0 +1,81 @@
+package streaks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/aoc"
+	"github.com/stretchr/testify/assert"
+)
+
+var calendar2024 = aoc.CalendarFor(2024)
+
+// solvedAfter returns completion times the given durations after each day's
+// unlock, starting at day 1.
+func solvedAfter(durations ...time.Duration) map[int]time.Time {
+	completed := make(map[int]time.Time)
+	for i, d := range durations {
+		completed[i+1] = calendar2024.Unlock(i + 1).Add(d)
+	}
+	return completed
+}
+
+func TestDeadline(t *testing.T) {
+	assert.Equal(t, calendar2024.Unlock(5), Deadline(calendar2024, 4, nil), "In the unlock timezone a day ends when the next one unlocks")
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	expected := time.Date(2024, time.December, 5, 0, 0, 0, 0, paris)
+	assert.Equal(t, expected, Deadline(calendar2024, 4, paris), "In a member's timezone a day ends at their midnight")
+}
+
+func TestCurrent(t *testing.T) {
+	completed := solvedAfter(time.Hour, time.Hour, 30*time.Hour, time.Hour, time.Hour)
+
+	now := calendar2024.Unlock(5).Add(2 * time.Hour)
+	assert.Equal(t, Streak{Start: 4, Length: 2}, Current(calendar2024, completed, nil, now), "Day 3 was completed too late and breaks the streak")
+
+	now = calendar2024.Unlock(6).Add(2 * time.Hour)
+	assert.Equal(t, Streak{Start: 4, Length: 2}, Current(calendar2024, completed, nil, now), "Today's day should not break the streak before its deadline")
+
+	now = calendar2024.Unlock(7).Add(2 * time.Hour)
+	assert.Equal(t, Streak{}, Current(calendar2024, completed, nil, now), "A missed deadline should end the streak")
+}
+
+func TestCurrent_MemberTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	// Puzzles unlock at 14:00 in Tokyo, so 12 hours later is past midnight.
+	completed := solvedAfter(time.Hour, 12*time.Hour)
+	now := calendar2024.Unlock(3).Add(time.Hour)
+
+	assert.Equal(t, Streak{Start: 1, Length: 2}, Current(calendar2024, completed, nil, now), "In the unlock timezone both days count")
+	assert.Equal(t, Streak{}, Current(calendar2024, completed, tokyo, now), "In Tokyo day 2 was completed the next day, which ends the streak")
+}
+
+func TestAtRisk(t *testing.T) {
+	completed := solvedAfter(time.Hour, time.Hour)
+
+	day, deadline, ok := AtRisk(calendar2024, completed, nil, calendar2024.Unlock(4).Add(-time.Hour), 2*time.Hour)
+	assert.True(t, ok, "The streak should be at risk an hour before the deadline")
+	assert.Equal(t, 3, day, "Day 3 should keep the streak going")
+	assert.Equal(t, calendar2024.Unlock(4), deadline, "Deadline should be the next unlock")
+
+	_, _, ok = AtRisk(calendar2024, completed, nil, calendar2024.Unlock(3).Add(time.Hour), 2*time.Hour)
+	assert.False(t, ok, "The streak should not be at risk long before the deadline")
+
+	completed[3] = calendar2024.Unlock(3).Add(time.Hour)
+	_, _, ok = AtRisk(calendar2024, completed, nil, calendar2024.Unlock(4).Add(-time.Hour), 2*time.Hour)
+	assert.False(t, ok, "A completed day should not be at risk")
+
+	_, _, ok = AtRisk(calendar2024, nil, nil, calendar2024.Unlock(4).Add(-time.Hour), 2*time.Hour)
+	assert.False(t, ok, "Without a streak there is nothing to lose")
+}
+
+func TestMilestone(t *testing.T) {
+	milestones := []int{5, 10, 25}
+
+	assert.Equal(t, 0, Milestone(milestones, 4), "No milestone should be reached yet")
+	assert.Equal(t, 5, Milestone(milestones, 7), "The highest milestone reached should be returned")
+	assert.Equal(t, 10, Milestone(milestones, 10), "A milestone should be reached on the day")
+}
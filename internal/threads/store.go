@@ -0,0 +1,139 @@
+// Package threads remembers the discussion thread the bot opened for each
+// puzzle day, so that it is opened once and its summary is posted once.
+package threads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/PaytonWebber/aoc-discord-bot/internal/storage"
+)
+
+const threadsFile = "threads.json"
+
+// Thread is the discussion thread of one puzzle day.
+type Thread struct {
+	ID string `json:"id"`
+	// Members are the Discord users the bot has added to the thread.
+	Members []string `json:"members,omitempty"`
+	// Closed is set once the day's results have been posted.
+	Closed bool `json:"closed,omitempty"`
+}
+
+// HasMember reports whether the bot has added the user to the thread.
+func (t Thread) HasMember(userID string) bool {
+	for _, member := range t.Members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps threads by year and day in a JSON file in the data directory.
+// It is safe for concurrent use.
+type Store struct {
+	path    string
+	mu      sync.RWMutex
+	threads map[int]map[int]Thread
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		path:    filepath.Join(dir, threadsFile),
+		threads: make(map[int]map[int]Thread),
+	}
+}
+
+// Load reads the stored threads. A missing file is not an error, it just
+// means no thread has been opened yet.
+func (s *Store) Load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading threads: %w", err)
+	}
+
+	threads := make(map[int]map[int]Thread)
+	if err := json.Unmarshal(data, &threads); err != nil {
+		return fmt.Errorf("error unmarshalling threads %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threads = threads
+	return nil
+}
+
+// Get returns the thread of a puzzle day.
+func (s *Store) Get(year, day int) (Thread, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	thread, ok := s.threads[year][day]
+	return thread, ok
+}
+
+// Open returns the days of the year whose thread has not been closed yet, in
+// order.
+func (s *Store) Open(year int) []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var days []int
+	for day, thread := range s.threads[year] {
+		if !thread.Closed {
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// Update changes the thread of a puzzle day with fn and saves the store. If
+// saving fails the change is undone.
+func (s *Store) Update(year, day int, fn func(*Thread)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.threads[year] == nil {
+		s.threads[year] = make(map[int]Thread)
+	}
+	days := s.threads[year]
+	previous, existed := days[day]
+	thread := previous
+	thread.Members = append([]string(nil), previous.Members...)
+	fn(&thread)
+	days[day] = thread
+
+	if err := s.save(); err != nil {
+		if existed {
+			days[day] = previous
+		} else {
+			delete(days, day)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the threads atomically. The caller must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.threads, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling threads: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	if err := storage.WriteFileAtomic(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("error storing threads: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package threads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreUpdateAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	err := store.Update(2024, 5, func(th *Thread) {
+		th.ID = "thread-5"
+		th.Members = append(th.Members, "user-1")
+	})
+	assert.NoError(t, err, "Update should not return an error")
+
+	reloaded := NewStore(dir)
+	assert.NoError(t, reloaded.Load(), "Load should not return an error")
+
+	thread, ok := reloaded.Get(2024, 5)
+	assert.True(t, ok, "Thread should be stored")
+	assert.Equal(t, "thread-5", thread.ID, "Thread ID should match")
+	assert.True(t, thread.HasMember("user-1"), "Added member should be remembered")
+	assert.False(t, thread.HasMember("user-2"), "Other users should not be members")
+
+	_, ok = reloaded.Get(2023, 5)
+	assert.False(t, ok, "Threads should be kept per year")
+}
+
+func TestStoreOpen(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, day := range []int{7, 3, 5} {
+		assert.NoError(t, store.Update(2024, day, func(th *Thread) { th.ID = "thread" }))
+	}
+	assert.NoError(t, store.Update(2024, 5, func(th *Thread) { th.Closed = true }))
+	assert.NoError(t, store.Update(2023, 1, func(th *Thread) { th.ID = "thread" }))
+
+	assert.Equal(t, []int{3, 7}, store.Open(2024), "Open should list the unclosed days in order")
+	assert.Empty(t, store.Open(2022), "A year without threads should have no open days")
+}
+
+func TestStoreLoadMissingFile(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	assert.NoError(t, store.Load(), "A missing file should not be an error")
+	assert.Empty(t, store.Open(2024), "There should be no threads")
+}
+
+func TestStoreUpdateUndoneOnSaveFailure(t *testing.T) {
+	dir := t.TempDir()
+	// A file where the data directory should be makes saving fail.
+	blocked := filepath.Join(dir, "blocked")
+	assert.NoError(t, os.WriteFile(blocked, nil, 0o644))
+	store := NewStore(filepath.Join(blocked, "data"))
+
+	err := store.Update(2024, 1, func(th *Thread) { th.ID = "thread-1" })
+
+	assert.Error(t, err, "Update should report the save failure")
+	_, ok := store.Get(2024, 1)
+	assert.False(t, ok, "The change should be undone")
+}